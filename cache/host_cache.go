@@ -1,20 +1,44 @@
 package cache
 
 import (
+	"cmp"
+	"maps"
 	"net"
 	"slices"
+	"time"
 
 	"github.com/ipastusi/netreact/event"
+	"github.com/ipastusi/netreact/oui"
 	"github.com/ipastusi/netreact/state"
 )
 
 type HostCache struct {
 	Items map[HostKey]HostDetails
+	// MAC vendors seen so far, kept apart from the cached hosts so that they outlive the pruned hosts
+	KnownVendors map[string]struct{}
+	// merge hosts with randomized MACs seen on the same IP into a single host
+	CollapseRandomizedMacs bool
+	// hosts not seen for this long are considered offline, 0 disables tracking the online / offline transitions
+	OfflineAfterSec uint
+	Presence        PresencePolicy
+	// hosts not seen for this long are pruned, 0 keeps the hosts forever
+	MaxAgeDays uint
+	// keys of the hosts by IP and by MAC, so that the other hosts of a new one are found without scanning all the hosts
+	byIp  map[string]map[HostKey]struct{}
+	byMac map[string]map[HostKey]struct{}
 }
 
+const (
+	maxTransitions = 100
+	maxPreviousIps = 20
+)
+
 func NewHostCache() HostCache {
 	return HostCache{
-		Items: map[HostKey]HostDetails{},
+		Items:        map[HostKey]HostDetails{},
+		KnownVendors: map[string]struct{}{},
+		byIp:         map[string]map[HostKey]struct{}{},
+		byMac:        map[string]map[HostKey]struct{}{},
 	}
 }
 
@@ -22,34 +46,113 @@ func FromAppState(appState state.AppState) HostCache {
 	cache := NewHostCache()
 	for _, stateItem := range appState.Items {
 		key := KeyFromIpMac(stateItem.Ip, stateItem.Mac)
-		cache.Items[key] = HostDetails{
-			FirstTs: stateItem.FirstTs,
-			LastTs:  stateItem.LastTs,
-			Count:   stateItem.Count,
+		hostDetails := HostDetails{
+			FirstTs:   stateItem.FirstTs,
+			LastTs:    stateItem.LastTs,
+			Count:     stateItem.Count,
+			Vendor:    stateItem.Vendor,
+			Labels:    stateItem.Labels,
+			Notes:     stateItem.Notes,
+			Interface: stateItem.Interface,
+			Vlan:      stateItem.Vlan,
+			ArpCounters: ArpCounters{
+				Requests:      stateItem.Requests,
+				Replies:       stateItem.Replies,
+				Probes:        stateItem.Probes,
+				Announcements: stateItem.Announcements,
+			},
+			PreviousIps: stateItem.PreviousIps,
+		}
+		for _, transition := range stateItem.Transitions {
+			hostDetails.Transitions = append(hostDetails.Transitions, Transition{Ts: transition.Ts, Online: transition.Online})
 		}
+		for _, interval := range stateItem.Presence {
+			hostDetails.Presence = append(hostDetails.Presence, Interval{StartTs: interval.Start, EndTs: interval.End})
+		}
+		cache.set(key, hostDetails)
+		cache.addKnownVendor(key.MacBytes())
+	}
+	for _, vendor := range appState.Vendors {
+		cache.KnownVendors[vendor] = struct{}{}
 	}
 	return cache
 }
 
 func (c *HostCache) ToAppState() state.AppState {
 	appState := state.NewAppState()
-	for cacheKey, cacheValue := range c.Items {
-		ip, mac := cacheKey.ToIpMac()
-		stateItem := state.Item{
-			Ip:      ip,
-			Mac:     mac,
-			FirstTs: cacheValue.FirstTs,
-			LastTs:  cacheValue.LastTs,
-			Count:   cacheValue.Count,
-		}
-		appState.Items = append(appState.Items, stateItem)
+	now := time.Now().UnixMilli()
+	for cacheKey := range c.Items {
+		appState.Items = append(appState.Items, c.stateItemWithStats(cacheKey, now))
 	}
 	slices.SortFunc(appState.Items, func(a, b state.Item) int {
 		return int(a.FirstTs - b.FirstTs)
 	})
+	appState.Vendors = slices.Sorted(maps.Keys(c.KnownVendors))
 	return appState
 }
 
+// StateItem converts a single host to the state format, without the presence statistics, which are only computed for
+// the whole state, as they are too expensive to compute for every packet
+func (c *HostCache) StateItem(key HostKey) state.Item {
+	cacheValue := c.Items[key]
+	ip, mac := key.ToIpMac()
+	vendor := cacheValue.Vendor
+	if vendor == "" {
+		vendor = oui.MacToVendor(key.MacBytes())
+	}
+	stateItem := state.Item{
+		Ip:                  ip,
+		Mac:                 mac,
+		FirstTs:             cacheValue.FirstTs,
+		LastTs:              cacheValue.LastTs,
+		Count:               cacheValue.Count,
+		LocallyAdministered: oui.IsLocallyAdministered(key.MacBytes()),
+		Multicast:           oui.IsMulticast(key.MacBytes()),
+		Vendor:              vendor,
+		Labels:              cacheValue.Labels,
+		Notes:               cacheValue.Notes,
+		Interface:           cacheValue.Interface,
+		Vlan:                cacheValue.Vlan,
+		Requests:            cacheValue.ArpCounters.Requests,
+		Replies:             cacheValue.ArpCounters.Replies,
+		Probes:              cacheValue.ArpCounters.Probes,
+		Announcements:       cacheValue.ArpCounters.Announcements,
+		PreviousIps:         cacheValue.PreviousIps,
+	}
+	for _, transition := range cacheValue.Transitions {
+		stateItem.Transitions = append(stateItem.Transitions, state.Transition{Ts: transition.Ts, Online: transition.Online})
+	}
+	for _, interval := range cacheValue.Presence {
+		stateItem.Presence = append(stateItem.Presence, state.Interval{Start: interval.StartTs, End: interval.EndTs})
+	}
+	return stateItem
+}
+
+// stateItemWithStats converts a single host to the state format, including the uptime and active hours as of ts
+func (c *HostCache) stateItemWithStats(key HostKey, ts int64) state.Item {
+	stateItem := c.StateItem(key)
+	if cacheValue := c.Items[key]; len(cacheValue.Presence) > 0 {
+		stateItem.UptimePct = c.Presence.UptimePct(cacheValue.Presence, cacheValue.FirstTs, ts)
+		stateItem.ActiveHours = ActiveHours(cacheValue.Presence, time.Local)
+	}
+	return stateItem
+}
+
+// Vendors returns a copy of the known MAC vendors, including the vendors of the hosts which are already pruned
+func (c *HostCache) Vendors() map[string]struct{} {
+	vendors := maps.Clone(c.KnownVendors)
+	if vendors == nil {
+		vendors = map[string]struct{}{}
+	}
+	return vendors
+}
+
+func (c *HostCache) addKnownVendor(mac net.HardwareAddr) {
+	if vendor := oui.MacToVendor(mac); vendor != oui.UnknownVendor {
+		c.KnownVendors[vendor] = struct{}{}
+	}
+}
+
 func (c *HostCache) IpAndMacMaps() (map[string]map[string]struct{}, map[string]map[string]struct{}) {
 	ipToMac := map[string]map[string]struct{}{}
 	macToIp := map[string]map[string]struct{}{}
@@ -76,18 +179,174 @@ func (c *HostCache) IpAndMacMaps() (map[string]map[string]struct{}, map[string]m
 func (c *HostCache) Update(arpEvent event.ArpEvent) event.ExtendedArpEvent {
 	key := KeyFromArpEvent(arpEvent)
 
-	val := c.Items[key]
+	val, ok := c.Items[key]
+	var collapsedMac net.HardwareAddr
+	if !ok && c.CollapseRandomizedMacs && oui.IsLocallyAdministered(arpEvent.Mac) {
+		if collapsedKey, found := c.randomizedHostForIp(arpEvent.Ip); found {
+			val = c.Items[collapsedKey]
+			c.delete(collapsedKey)
+			collapsedMac = collapsedKey.MacBytes()
+		}
+	}
+
 	if val.Count == 0 {
 		val.FirstTs = arpEvent.Ts
+		val.Vendor = oui.MacToVendor(arpEvent.Mac)
+		c.addKnownVendor(arpEvent.Mac)
+		if collapsedMac == nil {
+			val.PreviousIps = c.previousIpsForMac(arpEvent.Mac, key)
+		}
 	}
+	c.updateTransitions(&val, arpEvent.Ts)
+	val.Presence = c.Presence.Add(val.Presence, arpEvent.Ts)
 	val.LastTs = arpEvent.Ts
 	val.Count++
-	c.Items[key] = val
+	val.Interface = arpEvent.Interface
+	val.Vlan = arpEvent.Vlan
+	val.ArpCounters.update(arpEvent)
+	c.set(key, val)
 
 	return event.ExtendedArpEvent{
-		ArpEvent: arpEvent,
-		FirstTs:  val.FirstTs,
-		Count:    val.Count,
+		ArpEvent:     arpEvent,
+		FirstTs:      val.FirstTs,
+		Count:        val.Count,
+		CollapsedMac: collapsedMac,
+	}
+}
+
+// Prune removes the hosts not seen for MaxAgeDays, passing them to archive first, if not nil. If archiving fails, no host
+// is removed.
+func (c *HostCache) Prune(ts int64, archive func(items []state.Item) error) ([]state.Item, error) {
+	if c.MaxAgeDays == 0 {
+		return nil, nil
+	}
+
+	maxAgeMs := int64(c.MaxAgeDays) * 24 * 3600 * 1000
+	var keys []HostKey
+	var items []state.Item
+	for key, val := range c.Items {
+		if ts-val.LastTs > maxAgeMs {
+			keys = append(keys, key)
+			items = append(items, c.stateItemWithStats(key, ts))
+		}
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	if archive != nil {
+		if err := archive(items); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range keys {
+		c.delete(key)
+	}
+	return items, nil
+}
+
+// MarkOffline records the offline transition for all the online hosts not seen for OfflineAfterSec
+func (c *HostCache) MarkOffline(ts int64) {
+	if c.OfflineAfterSec == 0 {
+		return
+	}
+	for key, val := range c.Items {
+		if val.isOnline() && ts-val.LastTs >= c.offlineAfterMs() {
+			c.addTransition(&val, val.LastTs+c.offlineAfterMs(), false)
+			c.Items[key] = val
+		}
+	}
+}
+
+func (c *HostCache) updateTransitions(val *HostDetails, ts int64) {
+	if c.OfflineAfterSec == 0 {
+		return
+	}
+	if val.isOnline() && ts-val.LastTs >= c.offlineAfterMs() {
+		// offline, but not marked yet
+		c.addTransition(val, val.LastTs+c.offlineAfterMs(), false)
+	}
+	if !val.isOnline() {
+		c.addTransition(val, ts, true)
+	}
+}
+
+func (c *HostCache) addTransition(val *HostDetails, ts int64, online bool) {
+	val.Transitions = append(val.Transitions, Transition{Ts: ts, Online: online})
+	if len(val.Transitions) > maxTransitions {
+		val.Transitions = slices.Clone(val.Transitions[len(val.Transitions)-maxTransitions:])
+	}
+}
+
+func (c *HostCache) offlineAfterMs() int64 {
+	return int64(c.OfflineAfterSec) * 1000
+}
+
+// previousIpsForMac lists the other IP addresses of this MAC, least recently seen first. The unspecified address of the
+// ARP probes is skipped, as the host never used it.
+func (c *HostCache) previousIpsForMac(mac net.HardwareAddr, excludedKey HostKey) []string {
+	type ipLastTs struct {
+		ip     string
+		lastTs int64
+	}
+	var found []ipLastTs
+	for key := range c.byMac[string(mac)] {
+		if key != excludedKey && !net.IP(key.IpBytes()).IsUnspecified() {
+			found = append(found, ipLastTs{ip: net.IP(key.IpBytes()).String(), lastTs: c.Items[key].LastTs})
+		}
+	}
+	slices.SortFunc(found, func(a, b ipLastTs) int {
+		return cmp.Compare(a.lastTs, b.lastTs)
+	})
+
+	var ips []string
+	for _, f := range found[max(0, len(found)-maxPreviousIps):] {
+		ips = append(ips, f.ip)
+	}
+	return ips
+}
+
+// randomizedHostForIp finds the most recently seen host with a randomized MAC for the given IP
+func (c *HostCache) randomizedHostForIp(ip net.IP) (HostKey, bool) {
+	var found bool
+	var foundKey HostKey
+	var foundLastTs int64
+	for key := range c.byIp[string(ip.To4())] {
+		if !oui.IsLocallyAdministered(key.MacBytes()) {
+			continue
+		}
+		if val := c.Items[key]; !found || val.LastTs > foundLastTs {
+			found, foundKey, foundLastTs = true, key, val.LastTs
+		}
+	}
+	return foundKey, found
+}
+
+// set adds or updates the host, keeping the IP and MAC indexes in sync
+func (c *HostCache) set(key HostKey, val HostDetails) {
+	c.Items[key] = val
+	addToIndex(c.byIp, string(key.IpBytes()), key)
+	addToIndex(c.byMac, string(key.MacBytes()), key)
+}
+
+// delete removes the host, keeping the IP and MAC indexes in sync
+func (c *HostCache) delete(key HostKey) {
+	delete(c.Items, key)
+	removeFromIndex(c.byIp, string(key.IpBytes()), key)
+	removeFromIndex(c.byMac, string(key.MacBytes()), key)
+}
+
+func addToIndex(index map[string]map[HostKey]struct{}, indexKey string, key HostKey) {
+	if _, ok := index[indexKey]; !ok {
+		index[indexKey] = map[HostKey]struct{}{}
+	}
+	index[indexKey][key] = struct{}{}
+}
+
+func removeFromIndex(index map[string]map[HostKey]struct{}, indexKey string, key HostKey) {
+	delete(index[indexKey], key)
+	if len(index[indexKey]) == 0 {
+		delete(index, indexKey)
 	}
 }
 
@@ -1,10 +1,13 @@
 package cache_test
 
 import (
+	"errors"
 	"net"
 	"slices"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/gopacket/layers"
 	"github.com/ipastusi/netreact/cache"
 	"github.com/ipastusi/netreact/event"
 	"github.com/ipastusi/netreact/state"
@@ -41,8 +44,8 @@ func Test_FromAppState(t *testing.T) {
 		LastTs:  1749913040850,
 		Count:   1,
 	}
-	if hostDetails != expectedHostDetails {
-		t.Fatalf("unexpected host details, expected: %v, actual: %v", expectedHostDetails, hostDetails)
+	if diff := cmp.Diff(hostDetails, expectedHostDetails); diff != "" {
+		t.Fatalf("unexpected host details: %v", diff)
 	}
 
 	hostKey = cache.KeyFromIpMac("10.0.0.2", "00:00:00:04:05:06")
@@ -55,8 +58,8 @@ func Test_FromAppState(t *testing.T) {
 		LastTs:  1749913040852,
 		Count:   1,
 	}
-	if hostDetails != expectedHostDetails {
-		t.Fatalf("unexpected host details, expected: %v, actual: %v", expectedHostDetails, hostDetails)
+	if diff := cmp.Diff(hostDetails, expectedHostDetails); diff != "" {
+		t.Fatalf("unexpected host details: %v", diff)
 	}
 }
 
@@ -102,9 +105,10 @@ func Test_Update(t *testing.T) {
 		FirstTs: 1749913040850,
 		LastTs:  1749913040851,
 		Count:   2,
+		Vendor:  "XEROX CORPORATION",
 	}
-	if hostDetailsA != expectedHostDetailsA {
-		t.Fatalf("unexpected host details, expected: %v, actual: %v", expectedHostDetailsA, hostDetailsA)
+	if diff := cmp.Diff(hostDetailsA, expectedHostDetailsA); diff != "" {
+		t.Fatalf("unexpected host details: %v", diff)
 	}
 
 	hostKeyB := cache.KeyFromIpMac("10.0.0.2", "00:00:00:04:05:06")
@@ -113,9 +117,10 @@ func Test_Update(t *testing.T) {
 		FirstTs: 1749913040852,
 		LastTs:  1749913040852,
 		Count:   1,
+		Vendor:  "XEROX CORPORATION",
 	}
-	if hostDetailsB != expectedHostDetailsB {
-		t.Fatalf("unexpected host details, expected: %v, actual: %v", expectedHostDetailsB, hostDetailsB)
+	if diff := cmp.Diff(hostDetailsB, expectedHostDetailsB); diff != "" {
+		t.Fatalf("unexpected host details: %v", diff)
 	}
 }
 
@@ -153,16 +158,48 @@ func Test_ToAppState(t *testing.T) {
 			FirstTs: 1749913040850,
 			LastTs:  1749913040851,
 			Count:   2,
+			Vendor:  "XEROX CORPORATION",
 		}, {
 			Ip:      "10.0.0.2",
 			Mac:     "00:00:00:04:05:06",
 			FirstTs: 1749913040852,
 			LastTs:  1749913040852,
 			Count:   1,
+			Vendor:  "XEROX CORPORATION",
 		},
 	}
-	if !slices.Equal(appState.Items, expectedAppState.Items) {
-		t.Fatalf("unexpected app state items, expected: %v, actual: %v", appState.Items, expectedAppState.Items)
+	if diff := cmp.Diff(appState.Items, expectedAppState.Items); diff != "" {
+		t.Fatalf("unexpected app state items: %v", diff)
+	}
+}
+
+func Test_AppStateVendors(t *testing.T) {
+	t.Parallel()
+
+	appState := state.NewAppState()
+	appState.Items = []state.Item{
+		{
+			Ip:      "10.0.0.1",
+			Mac:     "b4:b6:86:01:02:03",
+			FirstTs: 1749913040850,
+			LastTs:  1749913040850,
+			Count:   1,
+		}, {
+			Ip:      "10.0.0.2",
+			Mac:     "da:a1:19:04:05:06",
+			FirstTs: 1749913040852,
+			LastTs:  1749913040852,
+			Count:   1,
+		},
+	}
+	appState.Vendors = []string{"Apple, Inc."}
+	hostCache := cache.FromAppState(appState)
+
+	// the vendor of a host no longer in the cache is kept, the unknown vendor of a randomized MAC is not
+	expectedVendors := []string{"Apple, Inc.", "Hewlett Packard"}
+	actualVendors := hostCache.ToAppState().Vendors
+	if !slices.Equal(actualVendors, expectedVendors) {
+		t.Fatalf("unexpected vendors, expected: %v, actual: %v", expectedVendors, actualVendors)
 	}
 }
 
@@ -258,3 +295,203 @@ func Test_IpAndMacMaps(t *testing.T) {
 		t.Fatalf("unexpected macToIp size for %v: %v", mac1.String(), size)
 	}
 }
+
+func Test_UpdateCollapseRandomizedMacs(t *testing.T) {
+	t.Parallel()
+
+	randomMac1, _ := net.ParseMAC("da:a1:19:00:00:01")
+	randomMac2, _ := net.ParseMAC("da:a1:19:00:00:02")
+	otherMac, _ := net.ParseMAC("00:00:00:00:00:03")
+
+	data := map[string]struct {
+		collapse             bool
+		secondMac            net.HardwareAddr
+		expectedSize         int
+		expectedCount        int
+		expectedCollapsedMac net.HardwareAddr
+	}{
+		"collapsed":            {true, randomMac2, 1, 2, randomMac1},
+		"collapse disabled":    {false, randomMac2, 2, 1, nil},
+		"not a randomized MAC": {true, otherMac, 2, 1, nil},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			hostCache := cache.NewHostCache()
+			hostCache.CollapseRandomizedMacs = d.collapse
+
+			hostCache.Update(event.ArpEvent{Ip: net.ParseIP("10.0.0.1"), Mac: randomMac1, Ts: 1749913040850})
+			// same randomized MAC on another IP is never collapsed
+			hostCache.Update(event.ArpEvent{Ip: net.ParseIP("10.0.0.2"), Mac: randomMac1, Ts: 1749913040851})
+			extArpEvent := hostCache.Update(event.ArpEvent{Ip: net.ParseIP("10.0.0.1"), Mac: d.secondMac, Ts: 1749913040852})
+
+			if size := len(hostCache.Items); size != d.expectedSize+1 {
+				t.Fatal("unexpected host cache size:", size)
+			}
+			if extArpEvent.Count != d.expectedCount {
+				t.Fatal("unexpected count:", extArpEvent.Count)
+			}
+			if !slices.Equal(extArpEvent.CollapsedMac, d.expectedCollapsedMac) {
+				t.Fatal("unexpected collapsed MAC:", extArpEvent.CollapsedMac)
+			}
+			if d.expectedCollapsedMac != nil && extArpEvent.FirstTs != 1749913040850 {
+				t.Fatal("unexpected first timestamp:", extArpEvent.FirstTs)
+			}
+		})
+	}
+}
+
+func Test_UpdateHostRecord(t *testing.T) {
+	t.Parallel()
+
+	hostCache := cache.NewHostCache()
+	hostCache.OfflineAfterSec = 60
+
+	mac, _ := net.ParseMAC("b4:b6:86:01:02:03")
+	startTs := int64(1749913040000)
+	events := []event.ArpEvent{
+		{Ip: net.ParseIP("10.0.0.1"), Mac: mac, Ts: startTs, TargetIp: net.ParseIP("10.0.0.254"), Operation: layers.ARPRequest, Interface: "eth0"},
+		{Ip: net.ParseIP("10.0.0.2"), Mac: mac, Ts: startTs + 1_000, TargetIp: net.ParseIP("10.0.0.254"), Operation: layers.ARPRequest, Interface: "eth0"},
+		{Ip: net.ParseIP("0.0.0.0"), Mac: mac, Ts: startTs + 2_000, TargetIp: net.ParseIP("10.0.0.3"), Operation: layers.ARPRequest, Interface: "eth0"},
+		{Ip: net.ParseIP("10.0.0.3"), Mac: mac, Ts: startTs + 3_000, TargetIp: net.ParseIP("10.0.0.3"), Operation: layers.ARPRequest, Interface: "eth0"},
+		{Ip: net.ParseIP("10.0.0.3"), Mac: mac, Ts: startTs + 4_000, TargetIp: net.ParseIP("10.0.0.254"), Operation: layers.ARPReply, Interface: "eth0", Vlan: 10},
+		// offline, not marked yet
+		{Ip: net.ParseIP("10.0.0.3"), Mac: mac, Ts: startTs + 100_000, TargetIp: net.ParseIP("10.0.0.254"), Operation: layers.ARPReply, Interface: "eth1", Vlan: 20},
+	}
+	for _, arpEvent := range events {
+		hostCache.Update(arpEvent)
+	}
+
+	// offline, marked
+	hostCache.MarkOffline(startTs + 159_999)
+	hostCache.MarkOffline(startTs + 160_000)
+	hostCache.MarkOffline(startTs + 170_000)
+
+	hostKey := cache.KeyFromIpMac("10.0.0.3", "b4:b6:86:01:02:03")
+	expectedHostDetails := cache.HostDetails{
+		FirstTs:   startTs + 3_000,
+		LastTs:    startTs + 100_000,
+		Count:     3,
+		Vendor:    "Hewlett Packard",
+		Interface: "eth1",
+		Vlan:      20,
+		ArpCounters: cache.ArpCounters{
+			Requests:      1,
+			Replies:       2,
+			Announcements: 1,
+		},
+		Transitions: []cache.Transition{
+			{Ts: startTs + 3_000, Online: true},
+			{Ts: startTs + 64_000, Online: false},
+			{Ts: startTs + 100_000, Online: true},
+			{Ts: startTs + 160_000, Online: false},
+		},
+		PreviousIps: []string{"10.0.0.1", "10.0.0.2"},
+	}
+	if diff := cmp.Diff(hostCache.Items[hostKey], expectedHostDetails); diff != "" {
+		t.Fatalf("unexpected host details: %v", diff)
+	}
+
+	// round trip through the app state
+	hostDetails := hostCache.Items[hostKey]
+	hostDetails.Labels = []string{"printer", "office"}
+	hostDetails.Notes = "2nd floor"
+	hostCache.Items[hostKey] = hostDetails
+
+	restoredHostCache := cache.FromAppState(hostCache.ToAppState())
+	if diff := cmp.Diff(restoredHostCache.Items, hostCache.Items); diff != "" {
+		t.Fatalf("unexpected host cache after round trip: %v", diff)
+	}
+}
+
+func Test_Prune(t *testing.T) {
+	t.Parallel()
+
+	const day = int64(24 * 3600 * 1000)
+	hostCache := cache.NewHostCache()
+	hostCache.MaxAgeDays = 30
+
+	mac1, _ := net.ParseMAC("00:00:00:01:02:03")
+	mac2, _ := net.ParseMAC("00:00:00:04:05:06")
+	startTs := int64(1749913040000)
+	hostCache.Update(event.ArpEvent{Ip: net.ParseIP("10.0.0.1"), Mac: mac1, Ts: startTs})
+	hostCache.Update(event.ArpEvent{Ip: net.ParseIP("10.0.0.2"), Mac: mac2, Ts: startTs + day})
+
+	// archiving fails, nothing is pruned
+	archiveErr := errors.New("disk full")
+	pruned, err := hostCache.Prune(startTs+31*day, func(items []state.Item) error {
+		return archiveErr
+	})
+	if !errors.Is(err, archiveErr) || len(pruned) != 0 || len(hostCache.Items) != 2 {
+		t.Fatalf("unexpected prune result, pruned: %v, error: %v", pruned, err)
+	}
+
+	var archived []state.Item
+	pruned, err = hostCache.Prune(startTs+31*day, func(items []state.Item) error {
+		archived = items
+		return nil
+	})
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if len(pruned) != 1 || pruned[0].Ip != "10.0.0.1" || pruned[0].Mac != mac1.String() {
+		t.Fatal("unexpected pruned hosts:", pruned)
+	}
+	if diff := cmp.Diff(archived, pruned); diff != "" {
+		t.Fatal("unexpected archived hosts:", diff)
+	}
+	if _, ok := hostCache.Items[cache.KeyFromIpMac("10.0.0.2", mac2.String())]; !ok || len(hostCache.Items) != 1 {
+		t.Fatal("unexpected hosts left:", hostCache.Items)
+	}
+
+	// without archive
+	pruned, err = hostCache.Prune(startTs+32*day+1, nil)
+	if err != nil || len(pruned) != 1 || len(hostCache.Items) != 0 {
+		t.Fatalf("unexpected prune result, pruned: %v, error: %v", pruned, err)
+	}
+}
+
+func Test_PruneForgetsPreviousIps(t *testing.T) {
+	t.Parallel()
+
+	const day = int64(24 * 3600 * 1000)
+	hostCache := cache.NewHostCache()
+	hostCache.MaxAgeDays = 30
+
+	mac, _ := net.ParseMAC("00:00:00:01:02:03")
+	startTs := int64(1749913040000)
+	hostCache.Update(event.ArpEvent{Ip: net.ParseIP("10.0.0.1"), Mac: mac, Ts: startTs})
+	hostCache.Update(event.ArpEvent{Ip: net.ParseIP("10.0.0.2"), Mac: mac, Ts: startTs + day})
+	if _, err := hostCache.Prune(startTs+31*day, nil); err != nil || len(hostCache.Items) != 1 {
+		t.Fatalf("unexpected prune result, hosts: %v, error: %v", hostCache.Items, err)
+	}
+
+	// the pruned host is no longer a previous IP of the MAC
+	hostCache.Update(event.ArpEvent{Ip: net.ParseIP("10.0.0.3"), Mac: mac, Ts: startTs + 31*day})
+	previousIps := hostCache.Host(cache.KeyFromIpMac("10.0.0.3", mac.String())).PreviousIps
+	if !slices.Equal(previousIps, []string{"10.0.0.2"}) {
+		t.Fatal("unexpected previous IPs:", previousIps)
+	}
+}
+
+func Test_PruneKeepsVendors(t *testing.T) {
+	t.Parallel()
+
+	const day = int64(24 * 3600 * 1000)
+	hostCache := cache.NewHostCache()
+	hostCache.MaxAgeDays = 30
+
+	mac, _ := net.ParseMAC("b4:b6:86:01:02:03")
+	startTs := int64(1749913040000)
+	hostCache.Update(event.ArpEvent{Ip: net.ParseIP("10.0.0.1"), Mac: mac, Ts: startTs})
+	if _, err := hostCache.Prune(startTs+31*day, nil); err != nil || len(hostCache.Items) != 0 {
+		t.Fatalf("unexpected prune result, hosts: %v, error: %v", hostCache.Items, err)
+	}
+
+	expectedVendors := []string{"Hewlett Packard"}
+	actualVendors := hostCache.ToAppState().Vendors
+	if !slices.Equal(actualVendors, expectedVendors) {
+		t.Fatalf("unexpected vendors, expected: %v, actual: %v", expectedVendors, actualVendors)
+	}
+}
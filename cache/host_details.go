@@ -1,7 +1,54 @@
 package cache
 
+import (
+	"github.com/google/gopacket/layers"
+	"github.com/ipastusi/netreact/event"
+)
+
 type HostDetails struct {
-	FirstTs int64
-	LastTs  int64
-	Count   int
+	FirstTs     int64
+	LastTs      int64
+	Count       int
+	Vendor      string
+	Labels      []string
+	Notes       string
+	Interface   string
+	Vlan        uint16
+	ArpCounters ArpCounters
+	Transitions []Transition
+	// IP addresses used by this MAC before this one, least recently seen first
+	PreviousIps []string
+	Presence    []Interval
+}
+
+type ArpCounters struct {
+	Requests      int
+	Replies       int
+	Probes        int
+	Announcements int
+}
+
+// Transition records the host going online or offline, as decided by HostCache.OfflineAfterSec
+type Transition struct {
+	Ts     int64
+	Online bool
+}
+
+func (c *ArpCounters) update(arpEvent event.ArpEvent) {
+	switch arpEvent.Operation {
+	case layers.ARPRequest:
+		c.Requests++
+	case layers.ARPReply:
+		c.Replies++
+	}
+
+	if arpEvent.Ip.IsUnspecified() {
+		c.Probes++
+	} else if arpEvent.Ip.Equal(arpEvent.TargetIp) {
+		c.Announcements++
+	}
+}
+
+func (d HostDetails) isOnline() bool {
+	return len(d.Transitions) > 0 && d.Transitions[len(d.Transitions)-1].Online
 }
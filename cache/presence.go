@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"math"
+	"time"
+)
+
+// Interval is a period of continuous presence of a host, merged from sightings not further apart than the presence gap
+type Interval struct {
+	StartTs int64
+	EndTs   int64
+}
+
+// PresencePolicy decides how the sightings of a host are merged into presence intervals, and for how long these are kept
+type PresencePolicy struct {
+	// sightings further apart start a new interval, 0 disables presence tracking
+	GapSec        uint
+	RetentionDays uint
+	MaxIntervals  int
+}
+
+// Add records the sighting of a host, either extending the latest interval or starting a new one, and drops the
+// intervals beyond retention
+func (p PresencePolicy) Add(intervals []Interval, ts int64) []Interval {
+	if p.GapSec == 0 {
+		return intervals
+	}
+
+	if n := len(intervals); n > 0 && ts-intervals[n-1].EndTs <= int64(p.GapSec)*1000 {
+		intervals[n-1].EndTs = max(intervals[n-1].EndTs, ts)
+	} else {
+		intervals = append(intervals, Interval{StartTs: ts, EndTs: ts})
+	}
+
+	first := 0
+	retentionMs := int64(p.RetentionDays) * 24 * 3600 * 1000
+	for first < len(intervals) && p.RetentionDays > 0 && intervals[first].EndTs < ts-retentionMs {
+		first++
+	}
+	if p.MaxIntervals > 0 {
+		first = max(first, len(intervals)-p.MaxIntervals)
+	}
+	if first > 0 {
+		intervals = append([]Interval(nil), intervals[first:]...)
+	}
+	return intervals
+}
+
+// RetentionStart returns the earliest timestamp still covered by the retention, relative to ts
+func (p PresencePolicy) RetentionStart(ts int64) int64 {
+	if p.RetentionDays == 0 {
+		return 0
+	}
+	return ts - int64(p.RetentionDays)*24*3600*1000
+}
+
+// UptimePct returns the percentage of the time since the host was first seen, or since the retention start if later,
+// during which the host was present, as of ts and rounded to 0.1
+func (p PresencePolicy) UptimePct(intervals []Interval, firstTs int64, ts int64) float64 {
+	fromTs := max(firstTs, p.RetentionStart(ts))
+	return math.Round(Uptime(intervals, fromTs, ts)*1000) / 10
+}
+
+// Uptime returns the share of the time between fromTs and toTs during which the host was present, between 0 and 1
+func Uptime(intervals []Interval, fromTs int64, toTs int64) float64 {
+	if toTs <= fromTs {
+		return 0
+	}
+
+	var presentMs int64
+	for _, interval := range intervals {
+		start, end := max(interval.StartTs, fromTs), min(interval.EndTs, toTs)
+		if end > start {
+			presentMs += end - start
+		}
+	}
+	return float64(presentMs) / float64(toTs-fromTs)
+}
+
+// ActiveHours returns the hours of the day, in the given location, during which the host is typically present, i.e. at
+// least half as long in total as during its busiest hour
+func ActiveHours(intervals []Interval, loc *time.Location) []int {
+	var hourMs [24]int64
+	for _, interval := range intervals {
+		start := time.UnixMilli(interval.StartTs).In(loc)
+		end := time.UnixMilli(interval.EndTs).In(loc)
+		for start.Before(end) {
+			// not using Truncate, as it ignores the location, e.g. with half-hour offsets
+			nextHour := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, loc).Add(time.Hour)
+			if nextHour.After(end) {
+				nextHour = end
+			}
+			hourMs[start.Hour()] += nextHour.Sub(start).Milliseconds()
+			start = nextHour
+		}
+	}
+
+	var busiestMs int64
+	for _, ms := range hourMs {
+		busiestMs = max(busiestMs, ms)
+	}
+	if busiestMs == 0 {
+		return nil
+	}
+
+	var hours []int
+	for hour, ms := range hourMs {
+		if ms*2 >= busiestMs {
+			hours = append(hours, hour)
+		}
+	}
+	return hours
+}
@@ -0,0 +1,114 @@
+package cache_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ipastusi/netreact/cache"
+)
+
+func Test_PresenceAdd(t *testing.T) {
+	t.Parallel()
+
+	const day = int64(24 * 3600 * 1000)
+	policy := cache.PresencePolicy{GapSec: 300, RetentionDays: 1, MaxIntervals: 2}
+
+	data := map[string]struct {
+		intervals []cache.Interval
+		ts        int64
+		expected  []cache.Interval
+	}{
+		"first sighting":   {nil, 1000, []cache.Interval{{1000, 1000}}},
+		"within gap":       {[]cache.Interval{{1000, 2000}}, 302_000, []cache.Interval{{1000, 302_000}}},
+		"beyond gap":       {[]cache.Interval{{1000, 2000}}, 302_001, []cache.Interval{{1000, 2000}, {302_001, 302_001}}},
+		"out of order":     {[]cache.Interval{{1000, 5000}}, 3000, []cache.Interval{{1000, 5000}}},
+		"max intervals":    {[]cache.Interval{{0, 0}, {1_000_000, 1_000_000}}, 2_000_000, []cache.Interval{{1_000_000, 1_000_000}, {2_000_000, 2_000_000}}},
+		"beyond retention": {[]cache.Interval{{0, 1000}}, day + 1001, []cache.Interval{{day + 1001, day + 1001}}},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			intervals := policy.Add(slices.Clone(d.intervals), d.ts)
+			if !slices.Equal(intervals, d.expected) {
+				t.Fatalf("unexpected intervals, expected: %v, got: %v", d.expected, intervals)
+			}
+		})
+	}
+}
+
+func Test_PresenceAddDisabled(t *testing.T) {
+	t.Parallel()
+
+	intervals := cache.PresencePolicy{}.Add(nil, 1000)
+	if intervals != nil {
+		t.Fatal("unexpected intervals:", intervals)
+	}
+}
+
+func Test_Uptime(t *testing.T) {
+	t.Parallel()
+
+	intervals := []cache.Interval{{0, 1000}, {2000, 4000}, {9000, 12_000}}
+
+	data := map[string]struct {
+		fromTs   int64
+		toTs     int64
+		expected float64
+	}{
+		"whole range":   {0, 10_000, 0.4},
+		"partial range": {3000, 5000, 0.5},
+		"empty range":   {5000, 5000, 0},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			uptime := cache.Uptime(intervals, d.fromTs, d.toTs)
+			if uptime != d.expected {
+				t.Fatalf("unexpected uptime, expected: %v, got: %v", d.expected, uptime)
+			}
+		})
+	}
+}
+
+func Test_ActiveHours(t *testing.T) {
+	t.Parallel()
+
+	at := func(day, hour, minute int) int64 {
+		return time.Date(2025, 7, day, hour, minute, 0, 0, time.UTC).UnixMilli()
+	}
+	intervals := []cache.Interval{
+		{at(1, 8, 0), at(1, 10, 0)},
+		{at(2, 8, 30), at(2, 10, 0)},
+		{at(2, 17, 0), at(2, 17, 20)},
+	}
+
+	hours := cache.ActiveHours(intervals, time.UTC)
+	expected := []int{8, 9}
+	if !slices.Equal(hours, expected) {
+		t.Fatalf("unexpected active hours, expected: %v, got: %v", expected, hours)
+	}
+
+	if hours := cache.ActiveHours(nil, time.UTC); hours != nil {
+		t.Fatal("unexpected active hours:", hours)
+	}
+}
+
+func Test_UptimePct(t *testing.T) {
+	t.Parallel()
+
+	const day = int64(24 * 3600 * 1000)
+	startTs := int64(1749913040000)
+	policy := cache.PresencePolicy{GapSec: 300, RetentionDays: 10}
+	intervals := []cache.Interval{{StartTs: startTs + 18*day, EndTs: startTs + 19*day}}
+
+	// measured from the first seen timestamp, or the retention start if later, not from the oldest interval kept
+	if pct := policy.UptimePct(intervals, startTs+16*day, startTs+20*day); pct != 25 {
+		t.Fatal("unexpected uptime since first seen:", pct)
+	}
+	if pct := policy.UptimePct(intervals, startTs, startTs+20*day); pct != 10 {
+		t.Fatal("unexpected uptime since retention start:", pct)
+	}
+}
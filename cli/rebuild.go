@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ipastusi/netreact/cache"
+	"github.com/ipastusi/netreact/importer"
+	"github.com/ipastusi/netreact/state"
+)
+
+// fileNames collects the values of a repeated flag
+type fileNames []string
+
+func (f *fileNames) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *fileNames) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// RunRebuildState rebuilds the state from the packet log, e.g. netreact rebuild-state -log netreact.log -s nrstate.json.
+// If the state file exists, the rebuilt hosts are imported into it, like with state import.
+func RunRebuildState(args []string, stdout io.Writer) error {
+	fs := newFlagSet("rebuild-state", "-log LOG_FILE... -s STATE_FILE")
+	var logFileNames fileNames
+	fs.Var(&logFileNames, "log", "log file, can be repeated")
+	rotated := fs.Bool("rotated", true, "also read the rotated log files, e.g. netreact.log.1 or netreact.log.2.gz")
+	stateFileName := fs.String("s", "", "state file to write, or to merge the rebuilt hosts into, if it exists")
+	from := fs.String("from", "", "only packets logged since, e.g. 2025-07-01 or 2025-07-01T12:00:00Z (default no limit)")
+	to := fs.String("to", "", "only packets logged until, e.g. 2025-07-31 or 2025-07-31T12:00:00Z (default no limit)")
+	keyFlags := addKeyFlags(fs)
+	if _, err := parseFlagSet(fs, args, 0, 0); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if len(logFileNames) == 0 {
+		return fmt.Errorf("missing log file")
+	} else if *stateFileName == "" {
+		return fmt.Errorf("missing state file")
+	}
+	fromTs, err := parseTime(*from, 0)
+	if err != nil {
+		return err
+	}
+	// a date only includes the whole day
+	toTs, err := parseTime(*to, 24*time.Hour-time.Millisecond)
+	if err != nil {
+		return err
+	}
+	key, err := keyFlags.read()
+	if err != nil {
+		return err
+	}
+
+	if *rotated {
+		if logFileNames, err = withRotated(logFileNames); err != nil {
+			return err
+		}
+	}
+	var appStates []state.AppState
+	var noPackets []string
+	for _, logFileName := range logFileNames {
+		items, err := readLogFile(logFileName, fromTs, toTs)
+		if errors.Is(err, importer.ErrNoPackets) {
+			// e.g. a log file just reopened after a restart, only an error if no log file has packet records at all
+			noPackets = append(noPackets, logFileName)
+			continue
+		} else if err != nil {
+			return fmt.Errorf("error reading log file %v: %w", logFileName, err)
+		}
+		appState := state.NewAppState()
+		appState.Items = items
+		appStates = append(appStates, appState)
+	}
+	if len(noPackets) > 0 && len(appStates) == 0 {
+		return fmt.Errorf("error reading log files %v: %w", strings.Join(noPackets, ", "), importer.ErrNoPackets)
+	}
+
+	existing, err := loadStateFile(*stateFileName, key)
+	if errors.Is(err, os.ErrNotExist) {
+		existing = state.NewAppState()
+	} else if err != nil {
+		return err
+	}
+	// the hosts already in the state only get their timestamps extended, so rebuilding from the same logs twice doesn't
+	// count the same packets twice
+	merged, _ := importer.Import(existing, state.Merge(appStates...).Items)
+
+	// fills in the MAC vendors and types of the rebuilt hosts
+	hostCache := cache.FromAppState(merged)
+	rebuilt := hostCache.ToAppState()
+	data, err := rebuilt.ToJson()
+	if err == nil {
+		data, err = state.Encode(*stateFileName, data, key)
+	}
+	if err != nil {
+		return err
+	}
+	if err = state.WriteFile(*stateFileName, data, true); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "Rebuilt %v hosts from %v log files into %v\n", len(rebuilt.Items), len(logFileNames), *stateFileName)
+	return err
+}
+
+// rotatedSuffix matches the suffixes of the rotated log files, but not e.g. the temporary files written while compressing
+var rotatedSuffix = regexp.MustCompile(`^\.\d+(\.gz|\.zst)?$`)
+
+// withRotated adds the rotated log files, e.g. netreact.log.1, to the log files, skipping the duplicates
+func withRotated(logFileNames []string) ([]string, error) {
+	var all []string
+	for _, logFileName := range logFileNames {
+		candidates, err := filepath.Glob(logFileName + ".*")
+		if err != nil {
+			return nil, err
+		}
+		rotated := slices.DeleteFunc(candidates, func(fileName string) bool {
+			return !rotatedSuffix.MatchString(strings.TrimPrefix(fileName, logFileName))
+		})
+		for _, fileName := range append([]string{logFileName}, rotated...) {
+			if !slices.Contains(all, fileName) {
+				all = append(all, fileName)
+			}
+		}
+	}
+	return all, nil
+}
+
+func readLogFile(fileName string, fromTs int64, toTs int64) ([]state.Item, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return importer.ReadLog(file, fromTs, toTs)
+}
+
+// parseTime parses a date or an RFC 3339 timestamp, adding dateOffset to a date, 0 if empty
+func parseTime(value string, dateOffset time.Duration) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t.Add(dateOffset).UnixMilli(), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %v, expected a date or an RFC 3339 timestamp", value)
+	}
+	return t.UnixMilli(), nil
+}
@@ -0,0 +1,103 @@
+package cli_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipastusi/netreact/cli"
+	"github.com/ipastusi/netreact/state"
+)
+
+func Test_RunRebuildState(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	logFileName := filepath.Join(dir, "netreact.log")
+	current := `{"time":"2025-07-09T10:00:00Z","level":"INFO","msg":"ARP packet received","IP":"192.168.1.10","MAC":"00:00:00:01:02:03"}` + "\n"
+	if err := os.WriteFile(logFileName, []byte(current), 0644); err != nil {
+		t.Fatal("error writing log file:", err)
+	}
+	var rotated bytes.Buffer
+	writer := gzip.NewWriter(&rotated)
+	_, _ = writer.Write([]byte(`{"time":"2025-07-08T10:00:00Z","level":"INFO","msg":"ARP packet received","IP":"192.168.1.10","MAC":"00:00:00:01:02:03"}` + "\n"))
+	_ = writer.Close()
+	if err := os.WriteFile(logFileName+".1.gz", rotated.Bytes(), 0644); err != nil {
+		t.Fatal("error writing rotated log file:", err)
+	}
+	// truncated, still being compressed
+	if err := os.WriteFile(logFileName+".2.gz.tmp", rotated.Bytes()[:10], 0644); err != nil {
+		t.Fatal("error writing temporary log file:", err)
+	}
+	stateFileName := filepath.Join(dir, "nrstate.json")
+
+	var stdout bytes.Buffer
+	if err := cli.RunRebuildState([]string{"-log", logFileName, "-s", stateFileName}, &stdout); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if stdout.String() != "Rebuilt 1 hosts from 2 log files into "+stateFileName+"\n" {
+		t.Fatal("unexpected output:", stdout.String())
+	}
+
+	stateBytes, err := os.ReadFile(stateFileName)
+	if err != nil {
+		t.Fatal("error reading state file:", err)
+	}
+	appState, err := state.Load(stateBytes)
+	if err != nil {
+		t.Fatal("invalid state file:", err)
+	}
+	if len(appState.Items) != 1 {
+		t.Fatal("unexpected state:", appState.Items)
+	}
+	item := appState.Items[0]
+	if item.FirstTs != 1751968800000 || item.LastTs != 1752055200000 || item.Count != 2 || item.Vendor != "XEROX CORPORATION" {
+		t.Fatal("unexpected host:", item)
+	}
+
+	// rebuilding from the same logs again doesn't count the same packets twice
+	if err = cli.RunRebuildState([]string{"-log", logFileName, "-s", stateFileName}, &stdout); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	stateBytes, err = os.ReadFile(stateFileName)
+	if err != nil {
+		t.Fatal("error reading state file:", err)
+	}
+	if appState, err = state.Load(stateBytes); err != nil {
+		t.Fatal("invalid state file:", err)
+	}
+	if len(appState.Items) != 1 || appState.Items[0].Count != 2 {
+		t.Fatal("unexpected state after rebuilding again:", appState.Items)
+	}
+}
+
+func Test_RunRebuildStateInvalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	stateFileName := filepath.Join(dir, "nrstate.json")
+	// written with logging.packets disabled
+	noPacketsFileName := filepath.Join(dir, "netreact.log")
+	noPackets := `{"time":"2025-07-09T10:00:00Z","level":"INFO","msg":"Event generated","IP":"192.168.1.10"}` + "\n"
+	if err := os.WriteFile(noPacketsFileName, []byte(noPackets), 0644); err != nil {
+		t.Fatal("error writing log file:", err)
+	}
+	data := map[string][]string{
+		"no packets":    {"-log", noPacketsFileName, "-s", stateFileName},
+		"no log file":   {"-s", stateFileName},
+		"no state file": {"-log", "netreact.log"},
+		"missing log":   {"-log", filepath.Join(t.TempDir(), "netreact.log"), "-s", stateFileName},
+		"invalid time":  {"-log", "netreact.log", "-s", stateFileName, "-from", "yesterday"},
+	}
+
+	for name, args := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if err := cli.RunRebuildState(args, &bytes.Buffer{}); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,494 @@
+package cli
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ipastusi/netreact/cache"
+	"github.com/ipastusi/netreact/importer"
+	"github.com/ipastusi/netreact/state"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+var stateCommands = map[string]func(args []string, stdout io.Writer) error{
+	"show":   showState,
+	"merge":  mergeStates,
+	"diff":   diffStates,
+	"export": exportState,
+	"import": importHosts,
+}
+
+// RunState runs a state file subcommand, e.g. netreact state show nrstate.json. These work on state files only, without
+// capturing any packets.
+func RunState(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing state subcommand, expected one of: %v", stateCommandNames())
+	}
+	command, ok := stateCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown state subcommand %v, expected one of: %v", args[0], stateCommandNames())
+	}
+	err := command(args[1:], stdout)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+func stateCommandNames() string {
+	var names []string
+	for name := range stateCommands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// host filtering and sorting, shared by show and export
+
+type hostQuery struct {
+	cidr    *string
+	mac     *string
+	vendor  *string
+	label   *string
+	since   *time.Duration
+	sort    *string
+	reverse *bool
+}
+
+var sortKeys = map[string]func(a, b state.Item) int{
+	"ip":     func(a, b state.Item) int { return state.CompareIps(a.Ip, b.Ip) },
+	"mac":    func(a, b state.Item) int { return cmp.Compare(a.Mac, b.Mac) },
+	"vendor": func(a, b state.Item) int { return cmp.Compare(a.Vendor, b.Vendor) },
+	"first":  func(a, b state.Item) int { return cmp.Compare(a.FirstTs, b.FirstTs) },
+	"last":   func(a, b state.Item) int { return cmp.Compare(a.LastTs, b.LastTs) },
+	"count":  func(a, b state.Item) int { return cmp.Compare(a.Count, b.Count) },
+	"uptime": func(a, b state.Item) int { return cmp.Compare(a.UptimePct, b.UptimePct) },
+}
+
+func addQueryFlags(fs *flag.FlagSet) hostQuery {
+	return hostQuery{
+		cidr:    fs.String("cidr", "", "only hosts with IP addresses in this CIDR range, e.g. 192.168.0.0/24"),
+		mac:     fs.String("mac", "", "only hosts with MAC addresses starting with this prefix, e.g. b4:b6:86"),
+		vendor:  fs.String("vendor", "", "only hosts with MAC vendors containing this text, case insensitive"),
+		label:   fs.String("label", "", "only hosts with this label"),
+		since:   fs.Duration("since", 0, "only hosts seen within this period, e.g. 24h (default all)"),
+		sort:    fs.String("sort", "first", "sort by ip, mac, vendor, first, last, count or uptime"),
+		reverse: fs.Bool("reverse", false, "reverse the sort order (default false)"),
+	}
+}
+
+// stateKey is the encryption key of the state files, shared by all the subcommands reading or writing them
+type stateKey struct {
+	keyFile *string
+	keyEnv  *string
+}
+
+func addKeyFlags(fs *flag.FlagSet) stateKey {
+	return stateKey{
+		keyFile: fs.String("key-file", "", "file with the state encryption key (default none)"),
+		keyEnv:  fs.String("key-env", "", "environment variable with the state encryption key (default none)"),
+	}
+}
+
+func (k stateKey) read() ([]byte, error) {
+	return state.ReadKey(k.keyFile, k.keyEnv)
+}
+
+func (q hostQuery) apply(items []state.Item, now time.Time) ([]state.Item, error) {
+	var cidrRange *net.IPNet
+	if *q.cidr != "" {
+		var err error
+		if _, cidrRange, err = net.ParseCIDR(*q.cidr); err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %v: %v", *q.cidr, err)
+		}
+	}
+	sortKey, ok := sortKeys[*q.sort]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort key: %v", *q.sort)
+	}
+
+	macPrefix := strings.ToLower(*q.mac)
+	vendor := strings.ToLower(*q.vendor)
+	var filtered []state.Item
+	for _, item := range items {
+		if cidrRange != nil && !cidrRange.Contains(net.ParseIP(item.Ip)) {
+			continue
+		}
+		if !strings.HasPrefix(item.Mac, macPrefix) {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(item.Vendor), vendor) {
+			continue
+		}
+		if *q.label != "" && !slices.Contains(item.Labels, *q.label) {
+			continue
+		}
+		if *q.since > 0 && item.LastTs < now.Add(-*q.since).UnixMilli() {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+
+	slices.SortStableFunc(filtered, func(a, b state.Item) int {
+		if *q.reverse {
+			return sortKey(b, a)
+		}
+		return sortKey(a, b)
+	})
+	return filtered, nil
+}
+
+// subcommands
+
+func showState(args []string, stdout io.Writer) error {
+	fs := newFlagSet("state show", "[flags] STATE_FILE")
+	query := addQueryFlags(fs)
+	keyFlags := addKeyFlags(fs)
+	fileNames, err := parseFlagSet(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	key, err := keyFlags.read()
+	if err != nil {
+		return err
+	}
+
+	appState, err := loadStateFile(fileNames[0], key)
+	if err != nil {
+		return err
+	}
+	items, err := query.apply(appState.Items, time.Now())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "IP Address\tMAC Address\tMAC Vendor\tFirst seen\tLast seen\tPacket count\tUptime\tLabels")
+	for _, item := range items {
+		_, _ = fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%.1f%%\t%v\n", item.Ip, item.Mac, item.Vendor, formatTs(item.FirstTs),
+			formatTs(item.LastTs), item.Count, item.UptimePct, strings.Join(item.Labels, ", "))
+	}
+	return w.Flush()
+}
+
+func mergeStates(args []string, stdout io.Writer) error {
+	fs := newFlagSet("state merge", "-o OUTPUT_FILE STATE_FILE...")
+	output := fs.String("o", "", "output state file")
+	keyFlags := addKeyFlags(fs)
+	fileNames, err := parseFlagSet(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return fmt.Errorf("missing output state file")
+	}
+	key, err := keyFlags.read()
+	if err != nil {
+		return err
+	}
+
+	var appStates []state.AppState
+	for _, fileName := range fileNames {
+		appState, err := loadStateFile(fileName, key)
+		if err != nil {
+			return err
+		}
+		appStates = append(appStates, appState)
+	}
+
+	// recalculates the presence statistics of the merged hosts
+	hostCache := cache.FromAppState(state.Merge(appStates...))
+	merged := hostCache.ToAppState()
+	data, err := merged.ToJson()
+	if err == nil {
+		data, err = state.Encode(*output, data, key)
+	}
+	if err != nil {
+		return err
+	}
+	if err = state.WriteFile(*output, data, false); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "Merged %v hosts from %v state files into %v\n", len(merged.Items), len(fileNames), *output)
+	return err
+}
+
+func diffStates(args []string, stdout io.Writer) error {
+	fs := newFlagSet("state diff", "[flags] OLD_STATE_FILE NEW_STATE_FILE")
+	keyFlags := addKeyFlags(fs)
+	fileNames, err := parseFlagSet(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	key, err := keyFlags.read()
+	if err != nil {
+		return err
+	}
+
+	oldState, err := loadStateFile(fileNames[0], key)
+	if err != nil {
+		return err
+	}
+	newState, err := loadStateFile(fileNames[1], key)
+	if err != nil {
+		return err
+	}
+
+	diff := state.Compare(oldState, newState)
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, item := range diff.Added {
+		_, _ = fmt.Fprintf(w, "+\t%v\t%v\t%v\n", item.Ip, item.Mac, item.Vendor)
+	}
+	for _, item := range diff.Removed {
+		_, _ = fmt.Fprintf(w, "-\t%v\t%v\t%v\n", item.Ip, item.Mac, item.Vendor)
+	}
+	for _, rebinding := range diff.Rebound {
+		_, _ = fmt.Fprintf(w, "~\t%v\t%v\t-> %v\n", rebinding.Ip, strings.Join(rebinding.OldMacs, ", "), strings.Join(rebinding.NewMacs, ", "))
+	}
+	return w.Flush()
+}
+
+func exportState(args []string, stdout io.Writer) error {
+	fs := newFlagSet("state export", "[flags] STATE_FILE")
+	format := fs.String("format", "csv", "output format: csv, json or markdown")
+	output := fs.String("o", "", "output file (default stdout)")
+	query := addQueryFlags(fs)
+	keyFlags := addKeyFlags(fs)
+	fileNames, err := parseFlagSet(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	key, err := keyFlags.read()
+	if err != nil {
+		return err
+	}
+
+	appState, err := loadStateFile(fileNames[0], key)
+	if err != nil {
+		return err
+	}
+	items, err := query.apply(appState.Items, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var export func(w io.Writer, items []state.Item) error
+	switch *format {
+	case "csv":
+		export = exportCsv
+	case "json":
+		export = exportJson
+	case "markdown":
+		export = exportMarkdown
+	default:
+		return fmt.Errorf("invalid export format: %v", *format)
+	}
+
+	if *output == "" {
+		return export(stdout, items)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	err = export(file, items)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func importHosts(args []string, stdout io.Writer) error {
+	fs := newFlagSet("state import", "-format FORMAT -s STATE_FILE INPUT_FILE...")
+	format := fs.String("format", "arpwatch", "input format: arpwatch (arp.dat) or arp-scan (its output, seen at the file modification time)")
+	stateFileName := fs.String("s", "", "state file to import into, created if it doesn't exist")
+	keyFlags := addKeyFlags(fs)
+	fileNames, err := parseFlagSet(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	if *stateFileName == "" {
+		return fmt.Errorf("missing state file")
+	}
+	if *format != "arpwatch" && *format != "arp-scan" {
+		return fmt.Errorf("invalid import format: %v", *format)
+	}
+	key, err := keyFlags.read()
+	if err != nil {
+		return err
+	}
+
+	appState, err := loadStateFile(*stateFileName, key)
+	if errors.Is(err, os.ErrNotExist) {
+		appState, err = state.NewAppState(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var imported []state.Item
+	for _, fileName := range fileNames {
+		items, err := readImportFile(fileName, *format)
+		if err != nil {
+			return fmt.Errorf("error importing %v: %w", fileName, err)
+		}
+		imported = append(imported, items...)
+	}
+	appState, added := importer.Import(appState, imported)
+
+	// fills in the MAC vendors and types of the imported hosts
+	hostCache := cache.FromAppState(appState)
+	appState = hostCache.ToAppState()
+	data, err := appState.ToJson()
+	if err != nil {
+		return err
+	}
+	if errs := state.ValidateState(data); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if data, err = state.Encode(*stateFileName, data, key); err != nil {
+		return err
+	}
+	if err = state.WriteFile(*stateFileName, data, true); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "Imported %v hosts, %v of them new, into %v\n", len(imported), added, *stateFileName)
+	return err
+}
+
+func readImportFile(fileName string, format string) ([]state.Item, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	if format == "arpwatch" {
+		return importer.ReadArpwatch(file)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return importer.ReadArpScan(file, info.ModTime().UnixMilli())
+}
+
+// export formats
+
+var exportHeader = []string{"ip", "mac", "vendor", "firstSeen", "lastSeen", "count", "interface", "vlan", "uptimePct", "labels", "notes"}
+
+func exportRow(item state.Item, formatTs func(ts int64) string) []string {
+	vlan := ""
+	if item.Vlan != 0 {
+		vlan = strconv.Itoa(int(item.Vlan))
+	}
+	return []string{
+		item.Ip,
+		item.Mac,
+		item.Vendor,
+		formatTs(item.FirstTs),
+		formatTs(item.LastTs),
+		strconv.Itoa(item.Count),
+		item.Interface,
+		vlan,
+		strconv.FormatFloat(item.UptimePct, 'f', 1, 64),
+		strings.Join(item.Labels, ";"),
+		item.Notes,
+	}
+}
+
+func exportCsv(w io.Writer, items []state.Item) error {
+	csvWriter := csv.NewWriter(w)
+	_ = csvWriter.Write(exportHeader)
+	for _, item := range items {
+		_ = csvWriter.Write(exportRow(item, func(ts int64) string {
+			return time.UnixMilli(ts).Format(time.RFC3339)
+		}))
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func exportJson(w io.Writer, items []state.Item) error {
+	appState := state.NewAppState()
+	appState.Items = append(appState.Items, items...)
+	data, err := json.MarshalIndent(appState, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func exportMarkdown(w io.Writer, items []state.Item) error {
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(exportHeader, " | ") + " |\n")
+	sb.WriteString(strings.Repeat("| --- ", len(exportHeader)) + "|\n")
+	for _, item := range items {
+		row := exportRow(item, formatTs)
+		for i := range row {
+			row[i] = strings.ReplaceAll(row[i], "|", "\\|")
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// helpers
+
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "Usage: netreact %v %v\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlagSet parses the flags and checks the number of the remaining file name arguments, -1 meaning no limit
+func parseFlagSet(fs *flag.FlagSet, args []string, minFiles int, maxFiles int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	fileNames := fs.Args()
+	if len(fileNames) < minFiles || (maxFiles >= 0 && len(fileNames) > maxFiles) {
+		fs.Usage()
+		return nil, fmt.Errorf("unexpected number of state files: %v", len(fileNames))
+	}
+	return fileNames, nil
+}
+
+// loadStateFile reads a state file, compressed or not, and encrypted with the key, if not nil
+func loadStateFile(fileName string, key []byte) (state.AppState, error) {
+	stateBytes, err := os.ReadFile(fileName)
+	if err != nil {
+		return state.AppState{}, err
+	}
+	if stateBytes, err = state.Decode(stateBytes, key); err != nil {
+		return state.AppState{}, fmt.Errorf("invalid state file %v: %w", fileName, err)
+	}
+	appState, err := state.Load(stateBytes)
+	if err != nil {
+		return state.AppState{}, fmt.Errorf("invalid state file %v: %w", fileName, err)
+	}
+	return appState, nil
+}
+
+func formatTs(ts int64) string {
+	return time.UnixMilli(ts).Format(timeFormat)
+}
@@ -0,0 +1,209 @@
+package cli_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipastusi/netreact/cli"
+	"github.com/ipastusi/netreact/state"
+)
+
+func writeStateFile(t *testing.T, fileName string, items ...state.Item) string {
+	t.Helper()
+	appState := state.NewAppState()
+	appState.Items = items
+	data, err := appState.ToJson()
+	if err != nil {
+		t.Fatal("error serializing state:", err)
+	}
+	fileName = filepath.Join(t.TempDir(), fileName)
+	if err = os.WriteFile(fileName, data, 0644); err != nil {
+		t.Fatal("error writing state file:", err)
+	}
+	return fileName
+}
+
+func Test_RunStateShow(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Now().UnixMilli()
+	fileName := writeStateFile(t, "nrstate.json",
+		state.Item{Ip: "10.0.0.10", Mac: "b4:b6:86:01:02:03", FirstTs: ts, LastTs: ts, Count: 1, Vendor: "Hewlett Packard"},
+		state.Item{Ip: "10.0.0.2", Mac: "00:00:00:01:02:03", FirstTs: ts - 1, LastTs: ts, Count: 5, Vendor: "XEROX CORPORATION"},
+		state.Item{Ip: "192.168.0.1", Mac: "00:00:00:04:05:06", FirstTs: ts - 2, LastTs: ts, Count: 2, Vendor: "XEROX CORPORATION"},
+	)
+
+	var stdout bytes.Buffer
+	err := cli.RunState([]string{"show", "-cidr", "10.0.0.0/24", "-sort", "ip", fileName}, &stdout)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "10.0.0.2 ") || !strings.HasPrefix(lines[2], "10.0.0.10 ") {
+		t.Fatal("unexpected output:", stdout.String())
+	}
+}
+
+func Test_RunStateExport(t *testing.T) {
+	t.Parallel()
+
+	fileName := writeStateFile(t, "nrstate.json",
+		state.Item{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1000, LastTs: 2000, Count: 5, Vendor: "XEROX CORPORATION", Vlan: 10, Labels: []string{"a", "b"}},
+		state.Item{Ip: "10.0.0.2", Mac: "b4:b6:86:01:02:03", FirstTs: 1000, LastTs: 1000, Count: 1, Vendor: "Hewlett Packard"},
+	)
+
+	var stdout bytes.Buffer
+	err := cli.RunState([]string{"export", "-format", "csv", "-vendor", "xerox", fileName}, &stdout)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	expected := "ip,mac,vendor,firstSeen,lastSeen,count,interface,vlan,uptimePct,labels,notes\n" +
+		"10.0.0.1,00:00:00:01:02:03,XEROX CORPORATION," + time.UnixMilli(1000).Format(time.RFC3339) + "," +
+		time.UnixMilli(2000).Format(time.RFC3339) + ",5,,10,0.0,a;b,\n"
+	if stdout.String() != expected {
+		t.Fatalf("unexpected output, expected: %v, got: %v", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	err = cli.RunState([]string{"export", "-format", "markdown", "-sort", "count", "-reverse", fileName}, &stdout)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "| 10.0.0.1 |") || !strings.HasPrefix(lines[3], "| 10.0.0.2 |") {
+		t.Fatal("unexpected output:", stdout.String())
+	}
+}
+
+func Test_RunStateMergeDiff(t *testing.T) {
+	t.Parallel()
+
+	fileName1 := writeStateFile(t, "sensor1.json",
+		state.Item{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1000, LastTs: 2000, Count: 5},
+	)
+	fileName2 := writeStateFile(t, "sensor2.json",
+		state.Item{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 500, LastTs: 1500, Count: 2},
+		state.Item{Ip: "10.0.0.2", Mac: "00:00:00:04:05:06", FirstTs: 1000, LastTs: 1000, Count: 1},
+	)
+	mergedFileName := filepath.Join(t.TempDir(), "merged.json")
+
+	var stdout bytes.Buffer
+	err := cli.RunState([]string{"merge", "-o", mergedFileName, fileName1, fileName2}, &stdout)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	mergedBytes, err := os.ReadFile(mergedFileName)
+	if err != nil {
+		t.Fatal("error reading merged state:", err)
+	}
+	merged, err := state.Load(mergedBytes)
+	if err != nil {
+		t.Fatal("invalid merged state:", err)
+	}
+	if len(merged.Items) != 2 || merged.Items[0].FirstTs != 500 || merged.Items[0].LastTs != 2000 || merged.Items[0].Count != 7 {
+		t.Fatal("unexpected merged state:", merged.Items)
+	}
+
+	stdout.Reset()
+	if err = cli.RunState([]string{"diff", fileName1, mergedFileName}, &stdout); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "+  10.0.0.2  00:00:00:04:05:06") || strings.Count(stdout.String(), "\n") != 1 {
+		t.Fatal("unexpected output:", stdout.String())
+	}
+}
+
+func Test_RunStateErrors(t *testing.T) {
+	t.Parallel()
+
+	fileName := writeStateFile(t, "nrstate.json")
+	data := map[string][]string{
+		"no subcommand":      {},
+		"unknown subcommand": {"remove", fileName},
+		"missing file":       {"show"},
+		"nonexistent file":   {"show", filepath.Join(t.TempDir(), "nonexistent.json")},
+		"invalid sort key":   {"show", "-sort", "size", fileName},
+		"invalid format":     {"export", "-format", "xml", fileName},
+		"missing output":     {"merge", fileName},
+	}
+
+	for name, args := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var stdout bytes.Buffer
+			if err := cli.RunState(args, &stdout); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func Test_RunStateImport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	arpDatFileName := filepath.Join(dir, "arp.dat")
+	arpDat := "0:0:0:1:2:3\t192.168.1.10\t1751972610\tprinter.lan\n"
+	if err := os.WriteFile(arpDatFileName, []byte(arpDat), 0644); err != nil {
+		t.Fatal("error writing arp.dat:", err)
+	}
+	stateFileName := filepath.Join(dir, "nrstate.json")
+
+	for i := range 2 {
+		var stdout bytes.Buffer
+		err := cli.RunState([]string{"import", "-format", "arpwatch", "-s", stateFileName, arpDatFileName}, &stdout)
+		if err != nil {
+			t.Fatalf("unexpected error in iteration %v: %v", i, err)
+		}
+	}
+
+	stateBytes, err := os.ReadFile(stateFileName)
+	if err != nil {
+		t.Fatal("error reading state file:", err)
+	}
+	appState, err := state.Load(stateBytes)
+	if err != nil {
+		t.Fatal("invalid state file:", err)
+	}
+	if len(appState.Items) != 1 || appState.Items[0].Vendor != "XEROX CORPORATION" || appState.Items[0].Notes != "printer.lan" {
+		t.Fatal("unexpected state:", appState.Items)
+	}
+}
+
+func Test_RunStateEncrypted(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	keyFileName := filepath.Join(dir, "netreact.key")
+	if err := os.WriteFile(keyFileName, []byte(strings.Repeat("ab", state.KeySize)+"\n"), 0600); err != nil {
+		t.Fatal("error writing key file:", err)
+	}
+	fileName := writeStateFile(t, "nrstate.json",
+		state.Item{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1000, LastTs: 2000, Count: 5},
+	)
+	encryptedFileName := filepath.Join(dir, "nrstate.json.gz")
+
+	// merged into an encrypted state file, which can only be read with the key
+	var stdout bytes.Buffer
+	err := cli.RunState([]string{"merge", "-key-file", keyFileName, "-o", encryptedFileName, fileName}, &stdout)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if err = cli.RunState([]string{"show", encryptedFileName}, &stdout); !errors.Is(err, state.ErrEncrypted) {
+		t.Fatal("expected encrypted state error, got:", err)
+	}
+
+	stdout.Reset()
+	if err = cli.RunState([]string{"show", "-key-file", keyFileName, encryptedFileName}, &stdout); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "10.0.0.1 ") {
+		t.Fatal("unexpected output:", stdout.String())
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/goccy/go-yaml"
+	"github.com/ipastusi/netreact/oui"
 	"golang.org/x/sys/unix"
 )
 
@@ -19,31 +22,172 @@ type EventTypeConfig struct {
 	NewUnexpected       *bool `yaml:"newUnexpected"`
 	NewIpForMac         *bool `yaml:"newIpForMac"`
 	NewMacForIp         *bool `yaml:"newMacForIp"`
+	NewRandomizedMac    *bool `yaml:"newRandomizedMac"`
 }
 
 type ExcludeConfig struct {
-	IpFile    *string `yaml:"ipFile"`
-	MacFile   *string `yaml:"macFile"`
-	IpMacFile *string `yaml:"ipMacFile"`
+	IpFile       *string            `yaml:"ipFile"`
+	MacFile      *string            `yaml:"macFile"`
+	IpMacFile    *string            `yaml:"ipMacFile"`
+	EventsConfig *EventFilterConfig `yaml:"events"`
+}
+
+type IncludeConfig struct {
+	IpFile       *string            `yaml:"ipFile"`
+	MacFile      *string            `yaml:"macFile"`
+	IpMacFile    *string            `yaml:"ipMacFile"`
+	EventsConfig *EventFilterConfig `yaml:"events"`
+}
+
+type EventFilterConfig struct {
+	IpFile      *string `yaml:"ipFile"`
+	MacFile     *string `yaml:"macFile"`
+	IpMacFile   *string `yaml:"ipMacFile"`
+	ExemptTypes []uint  `yaml:"exemptTypes"`
+}
+
+type BindingsConfig struct {
+	File            *string `yaml:"file"`
+	MissingAfterSec *uint   `yaml:"missingAfterSec"`
+}
+
+type ArpFloodConfig struct {
+	Enabled           *bool    `yaml:"enabled"`
+	WindowSec         *uint    `yaml:"windowSec"`
+	HostMaxPps        *uint    `yaml:"hostMaxPps"`
+	GlobalMinPps      *uint    `yaml:"globalMinPps"`
+	GlobalSpikeFactor *float64 `yaml:"globalSpikeFactor"`
+	LearningSec       *uint    `yaml:"learningSec"`
+}
+
+type ArpScanConfig struct {
+	Enabled    *bool `yaml:"enabled"`
+	WindowSec  *uint `yaml:"windowSec"`
+	MinTargets *uint `yaml:"minTargets"`
+}
+
+type ProxyArpConfig struct {
+	Enabled    *bool    `yaml:"enabled"`
+	WindowSec  *uint    `yaml:"windowSec"`
+	MinIps     *uint    `yaml:"minIps"`
+	RouterMacs []string `yaml:"routerMacs"`
+}
+
+type IpFlipFlopConfig struct {
+	Enabled   *bool `yaml:"enabled"`
+	WindowSec *uint `yaml:"windowSec"`
+	MinFlips  *uint `yaml:"minFlips"`
+}
+
+type IpConflictConfig struct {
+	Enabled         *bool `yaml:"enabled"`
+	ActiveWindowSec *uint `yaml:"activeWindowSec"`
+}
+
+type NewVendorConfig struct {
+	Enabled *bool `yaml:"enabled"`
+}
+
+type AnomalyConfig struct {
+	ArpFloodConfig   *ArpFloodConfig   `yaml:"arpFlood"`
+	ArpScanConfig    *ArpScanConfig    `yaml:"arpScan"`
+	ProxyArpConfig   *ProxyArpConfig   `yaml:"proxyArp"`
+	IpFlipFlopConfig *IpFlipFlopConfig `yaml:"ipFlipFlop"`
+	IpConflictConfig *IpConflictConfig `yaml:"ipConflict"`
+	NewVendorConfig  *NewVendorConfig  `yaml:"newVendor"`
+}
+
+type VendorRuleConfig struct {
+	Name        *string  `yaml:"name"`
+	Action      *string  `yaml:"action"`
+	CidrRange   *string  `yaml:"cidrRange"`
+	Vendors     []string `yaml:"vendors"`
+	Patterns    []string `yaml:"patterns"`
+	OuiPrefixes []string `yaml:"ouiPrefixes"`
 }
 
 type EventsConfig struct {
-	Directory           *string          `yaml:"directory"`
-	ExpectedCidrRange   *string          `yaml:"expectedCidrRange"`
-	AutoCleanupDelaySec *uint            `yaml:"autoCleanupDelaySec"`
-	ExcludeConfig       *ExcludeConfig   `yaml:"exclude"`
-	PacketEventConfig   *EventTypeConfig `yaml:"packet"`
-	HostEventConfig     *EventTypeConfig `yaml:"host"`
+	Directory           *string            `yaml:"directory"`
+	ExpectedCidrRange   *string            `yaml:"expectedCidrRange"`
+	AutoCleanupDelaySec *uint              `yaml:"autoCleanupDelaySec"`
+	ExcludeConfig       *ExcludeConfig     `yaml:"exclude"`
+	IncludeConfig       *IncludeConfig     `yaml:"include"`
+	BindingsConfig      *BindingsConfig    `yaml:"bindings"`
+	PacketEventConfig   *EventTypeConfig   `yaml:"packet"`
+	HostEventConfig     *EventTypeConfig   `yaml:"host"`
+	AnomalyConfig       *AnomalyConfig     `yaml:"anomaly"`
+	VendorRules         []VendorRuleConfig `yaml:"vendorRules"`
+}
+
+type RandomizedMacConfig struct {
+	Collapse           *bool `yaml:"collapse"`
+	ExcludeFromNewHost *bool `yaml:"excludeFromNewHost"`
+}
+
+type EncryptionConfig struct {
+	KeyFile *string `yaml:"keyFile"`
+	KeyEnv  *string `yaml:"keyEnv"`
+}
+
+type StateConfig struct {
+	AutosaveSec      *uint             `yaml:"autosaveSec"`
+	Backup           *bool             `yaml:"backup"`
+	Journal          *bool             `yaml:"journal"`
+	EncryptionConfig *EncryptionConfig `yaml:"encryption"`
+}
+
+type DatabaseConfig struct {
+	File                *string `yaml:"file"`
+	SightingIntervalSec *uint   `yaml:"sightingIntervalSec"`
+	RetentionDays       *uint   `yaml:"retentionDays"`
+}
+
+type PresenceConfig struct {
+	GapSec        *uint `yaml:"gapSec"`
+	RetentionDays *uint `yaml:"retentionDays"`
+	MaxIntervals  *uint `yaml:"maxIntervals"`
+}
+
+type LogRotationConfig struct {
+	MaxSizeMB   *uint `yaml:"maxSizeMB"`
+	MaxAgeHours *uint `yaml:"maxAgeHours"`
+	MaxBackups  *uint `yaml:"maxBackups"`
+	Compress    *bool `yaml:"compress"`
+}
+
+type LoggingConfig struct {
+	Level        *string  `yaml:"level"`
+	Format       *string  `yaml:"format"`
+	Destinations []string `yaml:"destinations"`
+	Packets      *bool    `yaml:"packets"`
+}
+
+type ReloadConfig struct {
+	WatchIntervalSec *uint `yaml:"watchIntervalSec"`
+}
+
+type HostsConfig struct {
+	OfflineAfterSec *uint           `yaml:"offlineAfterSec"`
+	PresenceConfig  *PresenceConfig `yaml:"presence"`
+	MaxAgeDays      *uint           `yaml:"maxAgeDays"`
+	ArchiveFile     *string         `yaml:"archiveFile"`
 }
 
 type Config struct {
-	IfaceName     *string       `yaml:"interface"`
-	LogFileName   *string       `yaml:"log"`
-	StateFileName *string       `yaml:"stateFile"`
-	BpfFilter     *string       `yaml:"bpfFilter"`
-	PromiscMode   *bool         `yaml:"promiscMode"`
-	Ui            *bool         `yaml:"ui"`
-	EventsConfig  *EventsConfig `yaml:"events"`
+	IfaceName           *string              `yaml:"interface"`
+	LogFileName         *string              `yaml:"log"`
+	LogRotationConfig   *LogRotationConfig   `yaml:"logRotation"`
+	LoggingConfig       *LoggingConfig       `yaml:"logging"`
+	StateFileName       *string              `yaml:"stateFile"`
+	StateConfig         *StateConfig         `yaml:"state"`
+	DatabaseConfig      *DatabaseConfig      `yaml:"database"`
+	BpfFilter           *string              `yaml:"bpfFilter"`
+	PromiscMode         *bool                `yaml:"promiscMode"`
+	Ui                  *bool                `yaml:"ui"`
+	ReloadConfig        *ReloadConfig        `yaml:"reload"`
+	RandomizedMacConfig *RandomizedMacConfig `yaml:"randomizedMac"`
+	HostsConfig         *HostsConfig         `yaml:"hosts"`
+	EventsConfig        *EventsConfig        `yaml:"events"`
 }
 
 func GetConfig(data []byte, iface *string, log *string, prom *bool, state *string) (Config, error) {
@@ -83,6 +227,18 @@ func (cfg *Config) resolveAbsPaths() error {
 	if err != nil {
 		return err
 	}
+	err = resolveIfNotNil(&cfg.DatabaseConfig.File)
+	if err != nil {
+		return err
+	}
+	err = resolveIfNotNil(&cfg.HostsConfig.ArchiveFile)
+	if err != nil {
+		return err
+	}
+	err = resolveIfNotNil(&cfg.StateConfig.EncryptionConfig.KeyFile)
+	if err != nil {
+		return err
+	}
 	err = resolveIfNotNil(&cfg.EventsConfig.Directory)
 	return err
 }
@@ -91,11 +247,48 @@ func (cfg *Config) applyDefaults() {
 	applyToNil(&cfg.BpfFilter, "arp")
 	applyToNil(&cfg.PromiscMode, false)
 	applyToNil(&cfg.Ui, true)
+	applyToNil(&cfg.LogRotationConfig, LogRotationConfig{})
+	applyToNil(&cfg.LogRotationConfig.MaxSizeMB, 0)
+	applyToNil(&cfg.LogRotationConfig.MaxAgeHours, 0)
+	applyToNil(&cfg.LogRotationConfig.MaxBackups, 5)
+	applyToNil(&cfg.LogRotationConfig.Compress, true)
+	applyToNil(&cfg.LoggingConfig, LoggingConfig{})
+	applyToNil(&cfg.LoggingConfig.Level, "info")
+	applyToNil(&cfg.LoggingConfig.Format, "json")
+	applyToNil(&cfg.LoggingConfig.Packets, true)
+	if cfg.LoggingConfig.Destinations == nil {
+		cfg.LoggingConfig.Destinations = []string{"file"}
+	}
+	applyToNil(&cfg.ReloadConfig, ReloadConfig{})
+	applyToNil(&cfg.ReloadConfig.WatchIntervalSec, 0)
+	applyToNil(&cfg.StateConfig, StateConfig{})
+	applyToNil(&cfg.StateConfig.AutosaveSec, 300)
+	applyToNil(&cfg.StateConfig.Backup, true)
+	applyToNil(&cfg.StateConfig.Journal, false)
+	applyToNil(&cfg.StateConfig.EncryptionConfig, EncryptionConfig{})
+	applyToNil(&cfg.DatabaseConfig, DatabaseConfig{})
+	applyToNil(&cfg.DatabaseConfig.SightingIntervalSec, 60)
+	applyToNil(&cfg.DatabaseConfig.RetentionDays, 30)
+	applyToNil(&cfg.HostsConfig, HostsConfig{})
+	applyToNil(&cfg.HostsConfig.OfflineAfterSec, 600)
+	applyToNil(&cfg.HostsConfig.PresenceConfig, PresenceConfig{})
+	applyToNil(&cfg.HostsConfig.PresenceConfig.GapSec, 300)
+	applyToNil(&cfg.HostsConfig.PresenceConfig.RetentionDays, 30)
+	applyToNil(&cfg.HostsConfig.PresenceConfig.MaxIntervals, 1000)
+	applyToNil(&cfg.HostsConfig.MaxAgeDays, 0)
+	applyToNil(&cfg.RandomizedMacConfig, RandomizedMacConfig{})
+	applyToNil(&cfg.RandomizedMacConfig.Collapse, false)
+	applyToNil(&cfg.RandomizedMacConfig.ExcludeFromNewHost, false)
 	applyToNil(&cfg.EventsConfig, EventsConfig{})
 	applyToNil(&cfg.EventsConfig.AutoCleanupDelaySec, 0)
 	applyToNil(&cfg.EventsConfig.ExpectedCidrRange, "0.0.0.0/0")
 	applyToNil(&cfg.EventsConfig.Directory, "")
 	applyToNil(&cfg.EventsConfig.ExcludeConfig, ExcludeConfig{})
+	applyToNil(&cfg.EventsConfig.ExcludeConfig.EventsConfig, EventFilterConfig{})
+	applyToNil(&cfg.EventsConfig.IncludeConfig, IncludeConfig{})
+	applyToNil(&cfg.EventsConfig.IncludeConfig.EventsConfig, EventFilterConfig{})
+	applyToNil(&cfg.EventsConfig.BindingsConfig, BindingsConfig{})
+	applyToNil(&cfg.EventsConfig.BindingsConfig.MissingAfterSec, 3600)
 
 	applyToNil(&cfg.EventsConfig.PacketEventConfig, EventTypeConfig{})
 	applyToNil(&cfg.EventsConfig.PacketEventConfig.Any, false)
@@ -105,6 +298,7 @@ func (cfg *Config) applyDefaults() {
 	applyToNil(&cfg.EventsConfig.PacketEventConfig.NewUnexpected, false)
 	applyToNil(&cfg.EventsConfig.PacketEventConfig.NewIpForMac, false)
 	applyToNil(&cfg.EventsConfig.PacketEventConfig.NewMacForIp, false)
+	applyToNil(&cfg.EventsConfig.PacketEventConfig.NewRandomizedMac, false)
 
 	applyToNil(&cfg.EventsConfig.HostEventConfig, EventTypeConfig{})
 	applyToNil(&cfg.EventsConfig.HostEventConfig.Any, false)
@@ -114,6 +308,41 @@ func (cfg *Config) applyDefaults() {
 	applyToNil(&cfg.EventsConfig.HostEventConfig.NewUnexpected, false)
 	applyToNil(&cfg.EventsConfig.HostEventConfig.NewIpForMac, false)
 	applyToNil(&cfg.EventsConfig.HostEventConfig.NewMacForIp, false)
+	applyToNil(&cfg.EventsConfig.HostEventConfig.NewRandomizedMac, false)
+
+	applyToNil(&cfg.EventsConfig.AnomalyConfig, AnomalyConfig{})
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpFloodConfig, ArpFloodConfig{})
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpFloodConfig.Enabled, false)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpFloodConfig.WindowSec, 10)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpFloodConfig.HostMaxPps, 20)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpFloodConfig.GlobalMinPps, 50)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpFloodConfig.GlobalSpikeFactor, 4.0)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpFloodConfig.LearningSec, 300)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpScanConfig, ArpScanConfig{})
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpScanConfig.Enabled, false)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpScanConfig.WindowSec, 60)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ArpScanConfig.MinTargets, 20)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ProxyArpConfig, ProxyArpConfig{})
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ProxyArpConfig.Enabled, false)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ProxyArpConfig.WindowSec, 3600)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.ProxyArpConfig.MinIps, 5)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.IpFlipFlopConfig, IpFlipFlopConfig{})
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.IpFlipFlopConfig.Enabled, false)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.IpFlipFlopConfig.WindowSec, 300)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.IpFlipFlopConfig.MinFlips, 3)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.IpConflictConfig, IpConflictConfig{})
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.IpConflictConfig.Enabled, false)
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.IpConflictConfig.ActiveWindowSec, 60)
+
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.NewVendorConfig, NewVendorConfig{})
+	applyToNil(&cfg.EventsConfig.AnomalyConfig.NewVendorConfig.Enabled, false)
+
+	for i := range cfg.EventsConfig.VendorRules {
+		rule := &cfg.EventsConfig.VendorRules[i]
+		applyToNil(&rule.Name, fmt.Sprintf("rule %v", i+1))
+		applyToNil(&rule.Action, "deny")
+		applyToNil(&rule.CidrRange, "0.0.0.0/0")
+	}
 }
 
 func (cfg *Config) validate() error {
@@ -134,15 +363,91 @@ func (cfg *Config) validate() error {
 		return fmt.Errorf("expected CIDR range should be IPv4, got: %v", ip)
 	}
 
-	excludeFiles := []*string{
-		cfg.EventsConfig.ExcludeConfig.IpFile,
-		cfg.EventsConfig.ExcludeConfig.MacFile,
-		cfg.EventsConfig.ExcludeConfig.IpMacFile,
+	arpFlood := cfg.EventsConfig.AnomalyConfig.ArpFloodConfig
+	if *arpFlood.WindowSec == 0 {
+		return fmt.Errorf("ARP flood window should be at least 1 second")
+	} else if *arpFlood.GlobalSpikeFactor < 1 {
+		return fmt.Errorf("ARP flood global spike factor should be at least 1, got: %v", *arpFlood.GlobalSpikeFactor)
+	}
+
+	arpScan := cfg.EventsConfig.AnomalyConfig.ArpScanConfig
+	if *arpScan.WindowSec == 0 {
+		return fmt.Errorf("ARP scan window should be at least 1 second")
+	} else if *arpScan.MinTargets < 2 {
+		return fmt.Errorf("ARP scan min targets should be at least 2, got: %v", *arpScan.MinTargets)
+	}
+
+	proxyArp := cfg.EventsConfig.AnomalyConfig.ProxyArpConfig
+	if *proxyArp.WindowSec == 0 {
+		return fmt.Errorf("proxy ARP window should be at least 1 second")
+	} else if *proxyArp.MinIps < 2 {
+		return fmt.Errorf("proxy ARP min IPs should be at least 2, got: %v", *proxyArp.MinIps)
+	}
+	for _, mac := range proxyArp.RouterMacs {
+		if _, err := net.ParseMAC(mac); err != nil {
+			return fmt.Errorf("invalid router MAC address %v: %v", mac, err)
+		}
+	}
+
+	ipFlipFlop := cfg.EventsConfig.AnomalyConfig.IpFlipFlopConfig
+	if *ipFlipFlop.WindowSec == 0 {
+		return fmt.Errorf("IP flip-flop window should be at least 1 second")
+	} else if *ipFlipFlop.MinFlips < 2 {
+		return fmt.Errorf("IP flip-flop min flips should be at least 2, got: %v", *ipFlipFlop.MinFlips)
+	}
+
+	if *cfg.EventsConfig.AnomalyConfig.IpConflictConfig.ActiveWindowSec == 0 {
+		return fmt.Errorf("IP conflict active window should be at least 1 second")
+	}
+
+	for _, rule := range cfg.EventsConfig.VendorRules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("invalid vendor rule %v: %v", *rule.Name, err)
+		}
+	}
+
+	if err := cfg.LoggingConfig.validate(*cfg.Ui); err != nil {
+		return err
+	}
+
+	if *cfg.StateConfig.Journal && cfg.StateFileName == nil {
+		return fmt.Errorf("state journal requires a state file")
+	} else if *cfg.StateConfig.Journal && cfg.DatabaseConfig.File != nil {
+		return fmt.Errorf("state journal can't be used with the database, which is written incrementally already")
+	} else if *cfg.StateConfig.Journal && *cfg.StateConfig.AutosaveSec == 0 {
+		// the journal is only emptied when the state file is saved
+		return fmt.Errorf("state journal requires the state autosave")
 	}
-	for _, excludeFile := range excludeFiles {
-		if excludeFile != nil {
-			if _, err := os.Stat(*excludeFile); err != nil {
-				return fmt.Errorf("file does not exist: %v", *excludeFile)
+
+	encryption := cfg.StateConfig.EncryptionConfig
+	if encryption.KeyFile != nil && encryption.KeyEnv != nil {
+		return fmt.Errorf("state encryption key should be read either from a file or from an environment variable, not both")
+	} else if *cfg.StateConfig.Journal && (encryption.KeyFile != nil || encryption.KeyEnv != nil) {
+		// the journal is written in plaintext, so it would leak the encrypted hosts
+		return fmt.Errorf("state journal can't be used with state encryption")
+	}
+
+	exclude, include := cfg.EventsConfig.ExcludeConfig, cfg.EventsConfig.IncludeConfig
+	inputFiles := []*string{
+		encryption.KeyFile,
+		exclude.IpFile,
+		exclude.MacFile,
+		exclude.IpMacFile,
+		exclude.EventsConfig.IpFile,
+		exclude.EventsConfig.MacFile,
+		exclude.EventsConfig.IpMacFile,
+		include.IpFile,
+		include.MacFile,
+		include.IpMacFile,
+		include.EventsConfig.IpFile,
+		include.EventsConfig.MacFile,
+		include.EventsConfig.IpMacFile,
+		cfg.EventsConfig.BindingsConfig.File,
+	}
+	for _, inputFile := range inputFiles {
+		if inputFile != nil {
+			if _, err := os.Stat(*inputFile); err != nil {
+				return fmt.Errorf("file does not exist: %v", *inputFile)
 			}
 		}
 	}
@@ -150,6 +455,54 @@ func (cfg *Config) validate() error {
 	return nil
 }
 
+func (logging LoggingConfig) validate(ui bool) error {
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, *logging.Level) {
+		return fmt.Errorf("log level should be debug, info, warn or error, got: %v", *logging.Level)
+	} else if *logging.Format != "json" && *logging.Format != "text" {
+		return fmt.Errorf("log format should be json or text, got: %v", *logging.Format)
+	} else if len(logging.Destinations) == 0 {
+		return fmt.Errorf("no log destinations provided")
+	}
+
+	for i, destination := range logging.Destinations {
+		if !slices.Contains([]string{"file", "stdout", "stderr"}, destination) {
+			return fmt.Errorf("log destination should be file, stdout or stderr, got: %v", destination)
+		} else if slices.Contains(logging.Destinations[:i], destination) {
+			return fmt.Errorf("duplicate log destination %v", destination)
+		} else if destination != "file" && ui {
+			// the user interface takes over the terminal
+			return fmt.Errorf("log destination %v can't be used with the user interface", destination)
+		}
+	}
+	return nil
+}
+
+func (rule VendorRuleConfig) validate() error {
+	if *rule.Action != "allow" && *rule.Action != "deny" {
+		return fmt.Errorf("action should be allow or deny, got: %v", *rule.Action)
+	} else if len(rule.Vendors) == 0 && len(rule.Patterns) == 0 && len(rule.OuiPrefixes) == 0 {
+		return fmt.Errorf("no vendors, patterns or OUI prefixes provided")
+	}
+
+	if ip, _, err := net.ParseCIDR(*rule.CidrRange); err != nil {
+		return fmt.Errorf("invalid CIDR range %v: %v", *rule.CidrRange, err)
+	} else if ip.To4() == nil {
+		return fmt.Errorf("CIDR range should be IPv4, got: %v", ip)
+	}
+
+	for _, pattern := range rule.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %v: %v", pattern, err)
+		}
+	}
+	for _, prefix := range rule.OuiPrefixes {
+		if _, err := oui.ParsePrefix(prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func applyIfNotNilOrEmpty(ptr **string, value *string) {
 	if value != nil && *value != "" {
 		*ptr = value
@@ -17,6 +17,8 @@ var (
 	defaultLogPtr = getDir(defaultLog)
 	state         = "nrstate.json"
 	statePtr      = getDir(state)
+	databasePtr   = getDir("netreact.db")
+	archivePtr    = getDir("archive.json")
 	defaultFilter = "arp"
 	customFilter  = "arp and src host not 0.0.0.0"
 	customDir     = "out"
@@ -24,10 +26,31 @@ var (
 	defaultDir    = getDir("")
 	defaultCidr   = "0.0.0.0/0"
 	customCidr    = "192.168.0.0/24"
+	keyEnv        = "NETREACT_STATE_KEY"
+	infoLevel     = "info"
+	debugLevel    = "debug"
+	jsonFormat    = "json"
+	textFormat    = "text"
 	yes           = true
 	no            = false
 	_0            = uint(0)
+	_2            = uint(2)
+	_3            = uint(3)
+	_5            = uint(5)
+	_10           = uint(10)
+	_20           = uint(20)
+	_24           = uint(24)
 	_30           = uint(30)
+	_50           = uint(50)
+	_60           = uint(60)
+	_90           = uint(90)
+	_100          = uint(100)
+	_300          = uint(300)
+	_600          = uint(600)
+	_1000         = uint(1000)
+	_3600         = uint(3600)
+	factor3       = 3.0
+	factor4       = 4.0
 )
 
 func Test_GetConfigCustom(t *testing.T) {
@@ -35,14 +58,60 @@ func Test_GetConfigCustom(t *testing.T) {
 
 	data := []byte(`
 log: custom.log
+logRotation:
+  maxSizeMB: 100
+  maxAgeHours: 24
+  maxBackups: 10
+  compress: false
+logging:
+  level: debug
+  format: text
+  destinations:
+    - file
+    - stderr
+  packets: false
 promiscMode: true
 stateFile: nrstate.json
+state:
+  autosaveSec: 60
+  backup: false
+  journal: false
+  encryption:
+    keyEnv: NETREACT_STATE_KEY
 bpfFilter: arp and src host not 0.0.0.0
 ui: false
+reload:
+  watchIntervalSec: 5
+database:
+  file: netreact.db
+  sightingIntervalSec: 300
+  retentionDays: 90
+hosts:
+  offlineAfterSec: 300
+  presence:
+    gapSec: 600
+    retentionDays: 60
+    maxIntervals: 100
+  maxAgeDays: 90
+  archiveFile: archive.json
+randomizedMac:
+  collapse: true
+  excludeFromNewHost: true
 events:
   directory: out
   autoCleanupDelaySec: 30
   expectedCidrRange: 192.168.0.0/24
+  exclude:
+    events:
+      exemptTypes:
+        - 305
+        - 309
+  include:
+    events:
+      exemptTypes:
+        - 301
+  bindings:
+    missingAfterSec: 600
   packet:
     any: true
     newLinkLocalUnicast: true
@@ -51,6 +120,7 @@ events:
     newUnexpected: true
     newIpForMac: true
     newMacForIp: true
+    newRandomizedMac: true
   host:
     any: true
     newLinkLocalUnicast: true
@@ -59,6 +129,35 @@ events:
     newUnexpected: true
     newIpForMac: true
     newMacForIp: true
+    newRandomizedMac: true
+  anomaly:
+    arpFlood:
+      enabled: true
+      windowSec: 2
+      hostMaxPps: 10
+      globalMinPps: 100
+      globalSpikeFactor: 3
+      learningSec: 600
+    arpScan:
+      enabled: true
+      windowSec: 30
+      minTargets: 50
+    proxyArp:
+      enabled: true
+      windowSec: 600
+      minIps: 10
+      routerMacs:
+        - 00:00:00:00:00:01
+        - 00:00:00:00:00:02
+    ipFlipFlop:
+      enabled: true
+      windowSec: 60
+      minFlips: 5
+    ipConflict:
+      enabled: true
+      activeWindowSec: 30
+    newVendor:
+      enabled: true
 `)
 
 	c, err := GetConfig(data, &iface.Name, &customLog, nil, nil)
@@ -67,17 +166,67 @@ events:
 	}
 
 	expC := Config{
-		IfaceName:     &iface.Name,
-		LogFileName:   &customLogPtr,
+		IfaceName:   &iface.Name,
+		LogFileName: &customLogPtr,
+		LogRotationConfig: &LogRotationConfig{
+			MaxSizeMB:   &_100,
+			MaxAgeHours: &_24,
+			MaxBackups:  &_10,
+			Compress:    &no,
+		},
+		LoggingConfig: &LoggingConfig{
+			Level:        &debugLevel,
+			Format:       &textFormat,
+			Destinations: []string{"file", "stderr"},
+			Packets:      &no,
+		},
 		PromiscMode:   &yes,
 		StateFileName: &statePtr,
 		BpfFilter:     &customFilter,
 		Ui:            &no,
+		ReloadConfig: &ReloadConfig{
+			WatchIntervalSec: &_5,
+		},
+		StateConfig: &StateConfig{
+			AutosaveSec: &_60,
+			Backup:      &no,
+			Journal:     &no,
+			EncryptionConfig: &EncryptionConfig{
+				KeyEnv: &keyEnv,
+			},
+		},
+		DatabaseConfig: &DatabaseConfig{
+			File:                &databasePtr,
+			SightingIntervalSec: &_300,
+			RetentionDays:       &_90,
+		},
+		HostsConfig: &HostsConfig{
+			OfflineAfterSec: &_300,
+			PresenceConfig: &PresenceConfig{
+				GapSec:        &_600,
+				RetentionDays: &_60,
+				MaxIntervals:  &_100,
+			},
+			MaxAgeDays:  &_90,
+			ArchiveFile: &archivePtr,
+		},
+		RandomizedMacConfig: &RandomizedMacConfig{
+			Collapse:           &yes,
+			ExcludeFromNewHost: &yes,
+		},
 		EventsConfig: &EventsConfig{
 			Directory:           &customDirPtr,
 			ExpectedCidrRange:   &customCidr,
 			AutoCleanupDelaySec: &_30,
-			ExcludeConfig:       &ExcludeConfig{},
+			ExcludeConfig: &ExcludeConfig{
+				EventsConfig: &EventFilterConfig{ExemptTypes: []uint{305, 309}},
+			},
+			IncludeConfig: &IncludeConfig{
+				EventsConfig: &EventFilterConfig{ExemptTypes: []uint{301}},
+			},
+			BindingsConfig: &BindingsConfig{
+				MissingAfterSec: &_600,
+			},
 			PacketEventConfig: &EventTypeConfig{
 				Any:                 &yes,
 				NewLinkLocalUnicast: &yes,
@@ -86,6 +235,7 @@ events:
 				NewUnexpected:       &yes,
 				NewIpForMac:         &yes,
 				NewMacForIp:         &yes,
+				NewRandomizedMac:    &yes,
 			},
 			HostEventConfig: &EventTypeConfig{
 				Any:                 &yes,
@@ -95,6 +245,40 @@ events:
 				NewUnexpected:       &yes,
 				NewIpForMac:         &yes,
 				NewMacForIp:         &yes,
+				NewRandomizedMac:    &yes,
+			},
+			AnomalyConfig: &AnomalyConfig{
+				ArpFloodConfig: &ArpFloodConfig{
+					Enabled:           &yes,
+					WindowSec:         &_2,
+					HostMaxPps:        &_10,
+					GlobalMinPps:      &_100,
+					GlobalSpikeFactor: &factor3,
+					LearningSec:       &_600,
+				},
+				ArpScanConfig: &ArpScanConfig{
+					Enabled:    &yes,
+					WindowSec:  &_30,
+					MinTargets: &_50,
+				},
+				ProxyArpConfig: &ProxyArpConfig{
+					Enabled:    &yes,
+					WindowSec:  &_600,
+					MinIps:     &_10,
+					RouterMacs: []string{"00:00:00:00:00:01", "00:00:00:00:00:02"},
+				},
+				IpFlipFlopConfig: &IpFlipFlopConfig{
+					Enabled:   &yes,
+					WindowSec: &_60,
+					MinFlips:  &_5,
+				},
+				IpConflictConfig: &IpConflictConfig{
+					Enabled:         &yes,
+					ActiveWindowSec: &_30,
+				},
+				NewVendorConfig: &NewVendorConfig{
+					Enabled: &yes,
+				},
 			},
 		},
 	}
@@ -116,17 +300,59 @@ func Test_GetConfigEmpty(t *testing.T) {
 	}
 
 	expC := Config{
-		IfaceName:     &iface.Name,
-		LogFileName:   &defaultLogPtr,
+		IfaceName:   &iface.Name,
+		LogFileName: &defaultLogPtr,
+		LogRotationConfig: &LogRotationConfig{
+			MaxSizeMB:   &_0,
+			MaxAgeHours: &_0,
+			MaxBackups:  &_5,
+			Compress:    &yes,
+		},
+		LoggingConfig: &LoggingConfig{
+			Level:        &infoLevel,
+			Format:       &jsonFormat,
+			Destinations: []string{"file"},
+			Packets:      &yes,
+		},
 		StateFileName: &statePtr,
 		BpfFilter:     &defaultFilter,
 		PromiscMode:   &yes,
 		Ui:            &yes,
+		ReloadConfig: &ReloadConfig{
+			WatchIntervalSec: &_0,
+		},
+		StateConfig: &StateConfig{
+			AutosaveSec:      &_300,
+			Backup:           &yes,
+			Journal:          &no,
+			EncryptionConfig: &EncryptionConfig{},
+		},
+		DatabaseConfig: &DatabaseConfig{
+			SightingIntervalSec: &_60,
+			RetentionDays:       &_30,
+		},
+		HostsConfig: &HostsConfig{
+			OfflineAfterSec: &_600,
+			PresenceConfig: &PresenceConfig{
+				GapSec:        &_300,
+				RetentionDays: &_30,
+				MaxIntervals:  &_1000,
+			},
+			MaxAgeDays: &_0,
+		},
+		RandomizedMacConfig: &RandomizedMacConfig{
+			Collapse:           &no,
+			ExcludeFromNewHost: &no,
+		},
 		EventsConfig: &EventsConfig{
 			Directory:           &defaultDir,
 			ExpectedCidrRange:   &defaultCidr,
 			AutoCleanupDelaySec: &_0,
-			ExcludeConfig:       &ExcludeConfig{},
+			ExcludeConfig:       &ExcludeConfig{EventsConfig: &EventFilterConfig{}},
+			IncludeConfig:       &IncludeConfig{EventsConfig: &EventFilterConfig{}},
+			BindingsConfig: &BindingsConfig{
+				MissingAfterSec: &_3600,
+			},
 			PacketEventConfig: &EventTypeConfig{
 				Any:                 &no,
 				NewLinkLocalUnicast: &no,
@@ -135,6 +361,7 @@ func Test_GetConfigEmpty(t *testing.T) {
 				NewUnexpected:       &no,
 				NewIpForMac:         &no,
 				NewMacForIp:         &no,
+				NewRandomizedMac:    &no,
 			},
 			HostEventConfig: &EventTypeConfig{
 				Any:                 &no,
@@ -144,6 +371,39 @@ func Test_GetConfigEmpty(t *testing.T) {
 				NewUnexpected:       &no,
 				NewIpForMac:         &no,
 				NewMacForIp:         &no,
+				NewRandomizedMac:    &no,
+			},
+			AnomalyConfig: &AnomalyConfig{
+				ArpFloodConfig: &ArpFloodConfig{
+					Enabled:           &no,
+					WindowSec:         &_10,
+					HostMaxPps:        &_20,
+					GlobalMinPps:      &_50,
+					GlobalSpikeFactor: &factor4,
+					LearningSec:       &_300,
+				},
+				ArpScanConfig: &ArpScanConfig{
+					Enabled:    &no,
+					WindowSec:  &_60,
+					MinTargets: &_20,
+				},
+				ProxyArpConfig: &ProxyArpConfig{
+					Enabled:   &no,
+					WindowSec: &_3600,
+					MinIps:    &_5,
+				},
+				IpFlipFlopConfig: &IpFlipFlopConfig{
+					Enabled:   &no,
+					WindowSec: &_300,
+					MinFlips:  &_3,
+				},
+				IpConflictConfig: &IpConflictConfig{
+					Enabled:         &no,
+					ActiveWindowSec: &_60,
+				},
+				NewVendorConfig: &NewVendorConfig{
+					Enabled: &no,
+				},
 			},
 		},
 	}
@@ -177,14 +437,56 @@ events:
 	expC := Config{
 		IfaceName:   &iface.Name,
 		LogFileName: &defaultLogPtr,
+		LogRotationConfig: &LogRotationConfig{
+			MaxSizeMB:   &_0,
+			MaxAgeHours: &_0,
+			MaxBackups:  &_5,
+			Compress:    &yes,
+		},
+		LoggingConfig: &LoggingConfig{
+			Level:        &infoLevel,
+			Format:       &jsonFormat,
+			Destinations: []string{"file"},
+			Packets:      &yes,
+		},
 		PromiscMode: &yes,
 		BpfFilter:   &customFilter,
 		Ui:          &yes,
+		ReloadConfig: &ReloadConfig{
+			WatchIntervalSec: &_0,
+		},
+		StateConfig: &StateConfig{
+			AutosaveSec:      &_300,
+			Backup:           &yes,
+			Journal:          &no,
+			EncryptionConfig: &EncryptionConfig{},
+		},
+		DatabaseConfig: &DatabaseConfig{
+			SightingIntervalSec: &_60,
+			RetentionDays:       &_30,
+		},
+		HostsConfig: &HostsConfig{
+			OfflineAfterSec: &_600,
+			PresenceConfig: &PresenceConfig{
+				GapSec:        &_300,
+				RetentionDays: &_30,
+				MaxIntervals:  &_1000,
+			},
+			MaxAgeDays: &_0,
+		},
+		RandomizedMacConfig: &RandomizedMacConfig{
+			Collapse:           &no,
+			ExcludeFromNewHost: &no,
+		},
 		EventsConfig: &EventsConfig{
 			Directory:           &customDirPtr,
 			ExpectedCidrRange:   &customCidr,
 			AutoCleanupDelaySec: &_0,
-			ExcludeConfig:       &ExcludeConfig{},
+			ExcludeConfig:       &ExcludeConfig{EventsConfig: &EventFilterConfig{}},
+			IncludeConfig:       &IncludeConfig{EventsConfig: &EventFilterConfig{}},
+			BindingsConfig: &BindingsConfig{
+				MissingAfterSec: &_3600,
+			},
 			PacketEventConfig: &EventTypeConfig{
 				Any:                 &no,
 				NewLinkLocalUnicast: &yes,
@@ -193,6 +495,7 @@ events:
 				NewUnexpected:       &no,
 				NewIpForMac:         &yes,
 				NewMacForIp:         &no,
+				NewRandomizedMac:    &no,
 			},
 			HostEventConfig: &EventTypeConfig{
 				Any:                 &no,
@@ -202,6 +505,39 @@ events:
 				NewUnexpected:       &no,
 				NewIpForMac:         &no,
 				NewMacForIp:         &no,
+				NewRandomizedMac:    &no,
+			},
+			AnomalyConfig: &AnomalyConfig{
+				ArpFloodConfig: &ArpFloodConfig{
+					Enabled:           &no,
+					WindowSec:         &_10,
+					HostMaxPps:        &_20,
+					GlobalMinPps:      &_50,
+					GlobalSpikeFactor: &factor4,
+					LearningSec:       &_300,
+				},
+				ArpScanConfig: &ArpScanConfig{
+					Enabled:    &no,
+					WindowSec:  &_60,
+					MinTargets: &_20,
+				},
+				ProxyArpConfig: &ProxyArpConfig{
+					Enabled:   &no,
+					WindowSec: &_3600,
+					MinIps:    &_5,
+				},
+				IpFlipFlopConfig: &IpFlipFlopConfig{
+					Enabled:   &no,
+					WindowSec: &_300,
+					MinFlips:  &_3,
+				},
+				IpConflictConfig: &IpConflictConfig{
+					Enabled:         &no,
+					ActiveWindowSec: &_60,
+				},
+				NewVendorConfig: &NewVendorConfig{
+					Enabled: &no,
+				},
 			},
 		},
 	}
@@ -238,6 +574,7 @@ events:
     newUnexpected: true
     newIpForMac: true
     newMacForIp: true
+    newRandomizedMac: true
   host:
     any: true
     newLinkLocalUnicast: true
@@ -246,6 +583,7 @@ events:
     newUnexpected: true
     newIpForMac: true
     newMacForIp: true
+    newRandomizedMac: true
 `)
 	_, err := GetConfig(data, &iface.Name, &defaultLog, nil, nil)
 	if err == nil {
@@ -319,6 +657,238 @@ func Test_GetConfigInvalidCidrRange3(t *testing.T) {
 	}
 }
 
+func Test_GetConfigInvalidLogging(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]string{
+		"level": `logging:
+  level: verbose`,
+		"format": `logging:
+  format: xml`,
+		"destination": `logging:
+  destinations: [syslog]`,
+		"duplicate destination": `logging:
+  destinations: [file, file]`,
+		"no destinations": `logging:
+  destinations: []`,
+		"stdout with ui": `logging:
+  destinations: [stdout]`,
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := GetConfig([]byte(d), &iface.Name, &defaultLog, &yes, &state)
+			if err == nil {
+				t.Fatal("No error on invalid data")
+			}
+		})
+	}
+}
+
+func Test_GetConfigInvalidStateEncryption(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`state:
+  encryption:
+    keyFile: config.go
+    keyEnv: NETREACT_STATE_KEY`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigInvalidStateJournal(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`state:
+  journal: true
+database:
+  file: netreact.db`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigInvalidStateJournalWithoutAutosave(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`state:
+  journal: true
+  autosaveSec: 0`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigInvalidEncryptedStateJournal(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`state:
+  journal: true
+  encryption:
+    keyEnv: NETREACT_STATE_KEY`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigInvalidArpFloodWindow(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`events:
+  anomaly:
+    arpFlood:
+      windowSec: 0`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigInvalidArpFloodSpikeFactor(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`events:
+  anomaly:
+    arpFlood:
+      globalSpikeFactor: 0.5`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigInvalidArpScanMinTargets(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`events:
+  anomaly:
+    arpScan:
+      minTargets: 1`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigInvalidRouterMac(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`events:
+  anomaly:
+    proxyArp:
+      routerMacs:
+        - invalid`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigInvalidIpFlipFlopMinFlips(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`events:
+  anomaly:
+    ipFlipFlop:
+      minFlips: 1`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigInvalidIpConflictWindow(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`events:
+  anomaly:
+    ipConflict:
+      activeWindowSec: 0`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigVendorRules(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`events:
+  vendorRules:
+    - vendors:
+        - Dell Inc.
+    - name: servers
+      action: allow
+      cidrRange: 10.0.1.0/24
+      patterns:
+        - ^Dell
+      ouiPrefixes:
+        - b4:b6:86`)
+	c, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err != nil {
+		t.Fatalf("Error loading yaml: %v", err)
+	}
+
+	defaultName, customName := "rule 1", "servers"
+	deny, allow := "deny", "allow"
+	customCidrRange := "10.0.1.0/24"
+	expRules := []VendorRuleConfig{
+		{
+			Name:      &defaultName,
+			Action:    &deny,
+			CidrRange: &defaultCidr,
+			Vendors:   []string{"Dell Inc."},
+		}, {
+			Name:        &customName,
+			Action:      &allow,
+			CidrRange:   &customCidrRange,
+			Patterns:    []string{"^Dell"},
+			OuiPrefixes: []string{"b4:b6:86"},
+		},
+	}
+
+	diff := cmp.Diff(c.EventsConfig.VendorRules, expRules)
+	if diff != "" {
+		t.Fatalf("Custom structs differ: %v", diff)
+	}
+}
+
+func Test_GetConfigInvalidVendorRules(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]string{
+		"invalid action": `
+    - action: alert
+      vendors: [Dell Inc.]`,
+		"no matchers": `
+    - action: deny`,
+		"invalid CIDR range": `
+    - cidrRange: 10.0.1.0/33
+      vendors: [Dell Inc.]`,
+		"invalid pattern": `
+    - patterns: ["(Dell"]`,
+		"invalid OUI prefix": `
+    - ouiPrefixes: [b4:b6:8x]`,
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			rules := []byte("events:\n  vendorRules:" + d)
+			_, err := GetConfig(rules, &iface.Name, &defaultLog, &yes, &state)
+			if err == nil {
+				t.Fatal("No error on invalid data")
+			}
+		})
+	}
+}
+
 func Test_GetConfigNonexistentExcludeIpFile(t *testing.T) {
 	t.Parallel()
 
@@ -355,6 +925,61 @@ func Test_GetConfigNonexistentExcludeIpMacFile(t *testing.T) {
 	}
 }
 
+func Test_GetConfigNonexistentEventExcludeFile(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`events:
+  exclude:
+    events:
+      ipFile: nonexistent.txt`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
+func Test_GetConfigNonexistentIncludeFile(t *testing.T) {
+	t.Parallel()
+
+	data := map[string][]byte{
+		"ip file": []byte(`events:
+  include:
+    ipFile: nonexistent.txt`),
+		"mac file": []byte(`events:
+  include:
+    macFile: nonexistent.txt`),
+		"ip-mac file": []byte(`events:
+  include:
+    ipMacFile: nonexistent.txt`),
+		"events ip-mac file": []byte(`events:
+  include:
+    events:
+      ipMacFile: nonexistent.txt`),
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := GetConfig(d, &iface.Name, &defaultLog, &yes, &state)
+			if err == nil {
+				t.Fatal("No error on invalid data")
+			}
+		})
+	}
+}
+
+func Test_GetConfigNonexistentBindingsFile(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`events:
+  bindings:
+    file: nonexistent.txt`)
+	_, err := GetConfig(data, &iface.Name, &defaultLog, &yes, &state)
+	if err == nil {
+		t.Fatal("No error on invalid data")
+	}
+}
+
 func getDir(path string) string {
 	pwd, _ := os.Getwd()
 	return filepath.Join(pwd, "..", path)
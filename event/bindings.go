@@ -0,0 +1,175 @@
+package event
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/ipastusi/netreact/oui"
+)
+
+type binding struct {
+	ip  string
+	mac string
+}
+
+// BindingDetector checks ARP packets against the expected IP-MAC bindings. An IP address may be bound to many MACs, and
+// a MAC to many IP addresses. A packet violates the bindings if its IP address is bound, but not to its MAC, or if its
+// MAC is bound, but not to its IP address. Each violating IP-MAC pair is reported once, until the IP address or the MAC
+// is seen with its bound counterpart again.
+// Bindings not seen within missingAfterMs are reported as missing, once until they are seen again.
+type BindingDetector struct {
+	bindings       []binding
+	ipToMacs       map[string][]string
+	macToIps       map[string][]string
+	missingAfterMs int64
+	startTs        int64
+	lastSeen       map[binding]int64
+	missing        map[binding]struct{}
+	reported       map[binding]struct{}
+}
+
+// NewBindingDetector takes the IP-MAC pairs in the format returned by ReadBindings. BINDING_MISSING events are disabled if
+// missingAfterSec is 0.
+func NewBindingDetector(pairs map[string]struct{}, missingAfterSec uint, startTs int64) *BindingDetector {
+	d := &BindingDetector{
+		ipToMacs:       map[string][]string{},
+		macToIps:       map[string][]string{},
+		missingAfterMs: int64(missingAfterSec) * 1000,
+		startTs:        startTs,
+		lastSeen:       map[binding]int64{},
+		missing:        map[binding]struct{}{},
+		reported:       map[binding]struct{}{},
+	}
+
+	for pair := range pairs {
+		ipStr, macStr, _ := strings.Cut(pair, ",")
+		ip := net.ParseIP(ipStr)
+		mac, err := net.ParseMAC(macStr)
+		if ip == nil || err != nil {
+			// already validated by ReadBindings
+			continue
+		}
+		b := binding{ip: ip.String(), mac: mac.String()}
+		d.bindings = append(d.bindings, b)
+		d.ipToMacs[b.ip] = append(d.ipToMacs[b.ip], b.mac)
+		d.macToIps[b.mac] = append(d.macToIps[b.mac], b.ip)
+	}
+
+	slices.SortFunc(d.bindings, func(a, b binding) int {
+		return strings.Compare(a.ip+","+a.mac, b.ip+","+b.mac)
+	})
+	for _, macs := range d.ipToMacs {
+		slices.Sort(macs)
+	}
+	for _, ips := range d.macToIps {
+		slices.SortFunc(ips, compareIps)
+	}
+	return d
+}
+
+func (d *BindingDetector) Detect(extArpEvent ExtendedArpEvent) []Alert {
+	if extArpEvent.Ip.IsUnspecified() {
+		// ARP probes are sent before the IP address is bound
+		return nil
+	}
+
+	b := binding{ip: extArpEvent.Ip.String(), mac: extArpEvent.Mac.String()}
+	expectedMacs, ipBound := d.ipToMacs[b.ip]
+	expectedIps, macBound := d.macToIps[b.mac]
+	if !ipBound && !macBound {
+		return nil
+	}
+
+	ipViolated := ipBound && !slices.Contains(expectedMacs, b.mac)
+	macViolated := macBound && !slices.Contains(expectedIps, b.ip)
+	if !ipViolated && !macViolated {
+		d.lastSeen[b] = extArpEvent.Ts
+		delete(d.missing, b)
+		// the violations of this binding are fixed, report them again if they recur
+		maps.DeleteFunc(d.reported, func(r binding, _ struct{}) bool {
+			return r.ip == b.ip || r.mac == b.mac
+		})
+		return nil
+	}
+
+	if _, ok := d.reported[b]; ok {
+		return nil
+	}
+	d.reported[b] = struct{}{}
+
+	notification := extArpEvent.toAlertNotification(BindingViolation)
+	if ipViolated {
+		notification.ExpectedMacs = expectedMacs
+	}
+	if macViolated {
+		notification.ExpectedIps = expectedIps
+	}
+	return []Alert{{Type: BindingViolation, Notification: notification}}
+}
+
+func (d *BindingDetector) Check(ts int64) []Alert {
+	if d.missingAfterMs == 0 {
+		return nil
+	}
+
+	var alerts []Alert
+	for _, b := range d.bindings {
+		if _, ok := d.missing[b]; ok {
+			continue
+		}
+
+		lastSeenTs, seen := d.lastSeen[b]
+		sinceTs := lastSeenTs
+		if !seen {
+			sinceTs = d.startTs
+		}
+		if ts-sinceTs < d.missingAfterMs {
+			continue
+		}
+
+		d.missing[b] = struct{}{}
+		alerts = append(alerts, toBindingMissingAlert(b, ts, lastSeenTs))
+	}
+	return alerts
+}
+
+func toBindingMissingAlert(b binding, ts int64, lastSeenTs int64) Alert {
+	mac, _ := net.ParseMAC(b.mac)
+	notification := Notification{
+		EventType:           BindingMissing.describe(),
+		Ip:                  b.ip,
+		Mac:                 b.mac,
+		Ts:                  ts,
+		MacVendor:           oui.MacToVendor(mac),
+		LocallyAdministered: oui.IsLocallyAdministered(mac),
+		Multicast:           oui.IsMulticast(mac),
+		LastSeenTs:          lastSeenTs,
+	}
+	return Alert{Type: BindingMissing, Notification: notification}
+}
+
+// ReadBindings reads the expected IP-MAC pairs, e.g. 10.0.0.1,b4:b6:86:01:02:03, one per line. Unlike the exclusion files,
+// only exact addresses are accepted, without expiry, though comments and blank lines are.
+func ReadBindings(reader io.Reader) (map[string]struct{}, error) {
+	pairs := map[string]struct{}{}
+	err := readEntries(reader, func(entry string, expiresTs int64) error {
+		ip, mac, ok := strings.Cut(entry, ",")
+		if !ok || expiresTs != noExpiry {
+			return fmt.Errorf("invalid binding: %v", entry)
+		} else if !IsValidIPv4(ip) {
+			return fmt.Errorf("invalid IP address: %v", ip)
+		} else if !IsValidMAC(mac) {
+			return fmt.Errorf("invalid MAC address: %v", mac)
+		}
+		pairs[entry] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
@@ -0,0 +1,132 @@
+package event_test
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ipastusi/netreact/event"
+)
+
+func Test_BindingDetectorViolation(t *testing.T) {
+	t.Parallel()
+
+	bindings := map[string]struct{}{
+		"10.0.0.1,00:00:00:00:00:01": {},
+		"10.0.0.2,00:00:00:00:00:02": {},
+		"10.0.0.2,00:00:00:00:00:03": {},
+		"10.0.0.4,00:00:00:00:00:04": {},
+		"10.0.0.5,00:00:00:00:00:04": {},
+	}
+	detector := event.NewBindingDetector(bindings, 0, 1749913040000)
+
+	data := []struct {
+		name         string
+		ip           string
+		mac          string
+		violation    bool
+		expectedMacs []string
+		expectedIps  []string
+	}{
+		{"bound pair", "10.0.0.1", "00:00:00:00:00:01", false, nil, nil},
+		{"one IP to many MACs", "10.0.0.2", "00:00:00:00:00:03", false, nil, nil},
+		{"one MAC to many IPs", "10.0.0.5", "00:00:00:00:00:04", false, nil, nil},
+		{"unbound pair", "10.0.0.3", "00:00:00:00:00:05", false, nil, nil},
+		{"ARP probe", "0.0.0.0", "00:00:00:00:00:06", false, nil, nil},
+		{"bound IP, other MAC", "10.0.0.2", "00:00:00:00:00:06", true, []string{"00:00:00:00:00:02", "00:00:00:00:00:03"}, nil},
+		{"bound IP, other MAC, reported", "10.0.0.2", "00:00:00:00:00:06", false, nil, nil},
+		{"bound IP, bound MAC again", "10.0.0.2", "00:00:00:00:00:02", false, nil, nil},
+		{"bound IP, other MAC, recurring", "10.0.0.2", "00:00:00:00:00:06", true, []string{"00:00:00:00:00:02", "00:00:00:00:00:03"}, nil},
+		{"bound MAC, other IP", "10.0.0.6", "00:00:00:00:00:04", true, nil, []string{"10.0.0.4", "10.0.0.5"}},
+		{"bound IP and MAC, not together", "10.0.0.1", "00:00:00:00:00:02", true, []string{"00:00:00:00:00:01"}, []string{"10.0.0.2"}},
+	}
+
+	for i, d := range data {
+		alerts := detector.Detect(newExtArpEvent(d.ip, d.mac, 1749913040000+int64(i)))
+		if !d.violation {
+			if len(alerts) != 0 {
+				t.Fatalf("%v: unexpected alerts: %v", d.name, alerts)
+			}
+			continue
+		}
+
+		if len(alerts) != 1 || alerts[0].Type != event.BindingViolation {
+			t.Fatalf("%v: expected a single violation, got: %v", d.name, alerts)
+		}
+		notification := alerts[0].Notification
+		if !slices.Equal(notification.ExpectedMacs, d.expectedMacs) || !slices.Equal(notification.ExpectedIps, d.expectedIps) {
+			t.Fatalf("%v: unexpected bindings, expected: %v %v, got: %v %v", d.name, d.expectedMacs, d.expectedIps, notification.ExpectedMacs, notification.ExpectedIps)
+		}
+	}
+}
+
+func Test_BindingDetectorMissing(t *testing.T) {
+	t.Parallel()
+
+	startTs := int64(1749913040000)
+	bindings := map[string]struct{}{
+		"10.0.0.1,00:00:00:00:00:01": {},
+		"10.0.0.2,00:00:00:00:00:02": {},
+	}
+	detector := event.NewBindingDetector(bindings, 60, startTs)
+
+	detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:01", startTs+30_000))
+
+	if alerts := detector.Check(startTs + 59_000); len(alerts) != 0 {
+		t.Fatal("unexpected alerts before the period ended:", alerts)
+	}
+
+	// never seen since start
+	alerts := detector.Check(startTs + 60_000)
+	if len(alerts) != 1 || alerts[0].Type != event.BindingMissing || alerts[0].Notification.Ip != "10.0.0.2" || alerts[0].Notification.LastSeenTs != 0 {
+		t.Fatal("unexpected alerts for the host never seen:", alerts)
+	}
+
+	// seen 60s ago, the other one already reported
+	alerts = detector.Check(startTs + 90_000)
+	if len(alerts) != 1 || alerts[0].Notification.Ip != "10.0.0.1" || alerts[0].Notification.LastSeenTs != startTs+30_000 {
+		t.Fatal("unexpected alerts for the host seen before:", alerts)
+	}
+
+	if alerts = detector.Check(startTs + 120_000); len(alerts) != 0 {
+		t.Fatal("unexpected repeated alerts:", alerts)
+	}
+
+	// seen again, then missing again
+	detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:01", startTs+130_000))
+	alerts = detector.Check(startTs + 190_000)
+	if len(alerts) != 1 || alerts[0].Notification.Ip != "10.0.0.1" {
+		t.Fatal("unexpected alerts for the host missing again:", alerts)
+	}
+}
+
+func Test_readBindings(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]struct {
+		data io.Reader
+		size int
+		ok   bool
+	}{
+		"two":                {strings.NewReader("10.0.0.1,00:00:00:00:00:01\n10.0.0.2,00:00:00:00:00:02\n"), 2, true},
+		"comments":           {strings.NewReader("# router\n10.0.0.1,00:00:00:00:00:01 # primary\n\n"), 1, true},
+		"invalid":            {strings.NewReader("10.0.0.1,00:00:00:00:00:01\ninvalid"), 0, false},
+		"range not allowed":  {strings.NewReader("10.0.0.0/24,00:00:00:00:00:01"), 0, false},
+		"prefix not allowed": {strings.NewReader("10.0.0.1,00:00:00:*"), 0, false},
+		"expiry not allowed": {strings.NewReader("10.0.0.1,00:00:00:00:00:01 expires=2025-12-31"), 0, false},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			bindings, err := event.ReadBindings(d.data)
+			if (err == nil && !d.ok) || (err != nil && d.ok) {
+				t.Fatalf("unexpected result for data %v, expected ok: %v, got error: %v", d.data, d.ok, err)
+			}
+			if len(bindings) != d.size {
+				t.Fatalf("unexpected size for data %v, expected: %v, got: %v", d.data, d.size, len(bindings))
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package event
+
+import (
+	"github.com/google/gopacket/layers"
+	"github.com/ipastusi/netreact/config"
+)
+
+type ipOwner struct {
+	mac    string
+	lastTs int64
+}
+
+// IpConflictDetector looks for duplicate IP addresses, as described in RFC 5227:
+// - ARP probes (sender IP 0.0.0.0) for an IP address recently used by, or probed for by, a different MAC.
+// - ARP announcements (sender IP same as target IP) for an IP address recently used by a different MAC.
+type IpConflictDetector struct {
+	windowMs int64
+	owners   map[string]ipOwner
+	probes   map[string]ipOwner
+	// IP and both MACs to the timestamp of the last report
+	reported     map[[3]string]int64
+	lastSweepSec int64
+}
+
+func NewIpConflictDetector(cfg config.IpConflictConfig) *IpConflictDetector {
+	return &IpConflictDetector{
+		windowMs: int64(*cfg.ActiveWindowSec) * 1000,
+		owners:   map[string]ipOwner{},
+		probes:   map[string]ipOwner{},
+		reported: map[[3]string]int64{},
+	}
+}
+
+func (d *IpConflictDetector) Detect(extArpEvent ExtendedArpEvent) []Alert {
+	ts, mac := extArpEvent.Ts, extArpEvent.Mac.String()
+	d.sweep(ts)
+
+	if extArpEvent.Ip.IsUnspecified() {
+		target := extArpEvent.TargetIp
+		if extArpEvent.Operation != layers.ARPRequest || target == nil || target.IsUnspecified() {
+			return nil
+		}
+
+		targetIp := target.String()
+		alerts := d.check(extArpEvent, targetIp, d.owners[targetIp], "probe")
+		alerts = append(alerts, d.check(extArpEvent, targetIp, d.probes[targetIp], "probe")...)
+		d.probes[targetIp] = ipOwner{mac: mac, lastTs: ts}
+		return alerts
+	}
+
+	ip := extArpEvent.Ip.String()
+	var alerts []Alert
+	if extArpEvent.TargetIp != nil && extArpEvent.TargetIp.Equal(extArpEvent.Ip) {
+		alerts = d.check(extArpEvent, ip, d.owners[ip], "announcement")
+	}
+	d.owners[ip] = ipOwner{mac: mac, lastTs: ts}
+	return alerts
+}
+
+func (d *IpConflictDetector) check(extArpEvent ExtendedArpEvent, ip string, owner ipOwner, conflictType string) []Alert {
+	ts, mac := extArpEvent.Ts, extArpEvent.Mac.String()
+	if owner.mac == "" || owner.mac == mac || ts-owner.lastTs > d.windowMs {
+		return nil
+	}
+
+	key := [3]string{ip, min(mac, owner.mac), max(mac, owner.mac)}
+	if reportedTs, ok := d.reported[key]; ok && ts-reportedTs <= d.windowMs {
+		return nil
+	}
+	d.reported[key] = ts
+
+	notification := extArpEvent.toAlertNotification(IpConflict)
+	notification.Ip = ip
+	notification.ConflictType = conflictType
+	notification.ConflictingMac = owner.mac
+	return []Alert{{Type: IpConflict, Notification: notification}}
+}
+
+// sweep forgets the owners, probes and reports older than the window, at most once per window
+func (d *IpConflictDetector) sweep(ts int64) {
+	sec := ts / 1000
+	if (sec-d.lastSweepSec)*1000 < d.windowMs {
+		return
+	}
+	d.lastSweepSec = sec
+
+	for _, m := range []map[string]ipOwner{d.owners, d.probes} {
+		for ip, owner := range m {
+			if ts-owner.lastTs > d.windowMs {
+				delete(m, ip)
+			}
+		}
+	}
+	for key, reportedTs := range d.reported {
+		if ts-reportedTs > d.windowMs {
+			delete(d.reported, key)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/ipastusi/netreact/config"
+	"github.com/ipastusi/netreact/event"
+)
+
+func newIpConflictDetector(activeWindowSec uint) *event.IpConflictDetector {
+	enabled := true
+	return event.NewIpConflictDetector(config.IpConflictConfig{
+		Enabled:         &enabled,
+		ActiveWindowSec: &activeWindowSec,
+	})
+}
+
+func Test_IpConflictDetector(t *testing.T) {
+	t.Parallel()
+
+	startTs := int64(1749913040000)
+	macA, macB, macC := "00:00:00:00:00:0a", "00:00:00:00:00:0b", "00:00:00:00:00:0c"
+
+	data := map[string]struct {
+		packets          []event.ExtendedArpEvent
+		expectedType     string
+		expectedMac      string
+		expectedConflict string
+	}{
+		"probe for active IP": {
+			[]event.ExtendedArpEvent{
+				newArpRequest("10.0.0.1", macA, "10.0.0.254", startTs),
+				newArpRequest("0.0.0.0", macB, "10.0.0.1", startTs+1000),
+			},
+			"probe", macB, macA,
+		},
+		"simultaneous probes": {
+			[]event.ExtendedArpEvent{
+				newArpRequest("0.0.0.0", macA, "10.0.0.1", startTs),
+				newArpRequest("0.0.0.0", macB, "10.0.0.1", startTs+500),
+			},
+			"probe", macB, macA,
+		},
+		"announcement for active IP": {
+			[]event.ExtendedArpEvent{
+				newArpReply("10.0.0.1", macA, startTs),
+				newArpRequest("10.0.0.1", macB, "10.0.0.1", startTs+1000),
+			},
+			"announcement", macB, macA,
+		},
+		"probe for inactive IP": {
+			[]event.ExtendedArpEvent{
+				newArpRequest("10.0.0.1", macA, "10.0.0.254", startTs),
+				newArpRequest("0.0.0.0", macB, "10.0.0.1", startTs+61000),
+			},
+			"", "", "",
+		},
+		"probe for own IP": {
+			[]event.ExtendedArpEvent{
+				newArpRequest("10.0.0.1", macA, "10.0.0.254", startTs),
+				newArpRequest("0.0.0.0", macA, "10.0.0.1", startTs+1000),
+			},
+			"", "", "",
+		},
+		"probe for another IP": {
+			[]event.ExtendedArpEvent{
+				newArpRequest("10.0.0.1", macA, "10.0.0.254", startTs),
+				newArpRequest("0.0.0.0", macC, "10.0.0.2", startTs+1000),
+			},
+			"", "", "",
+		},
+		"request for active IP": {
+			[]event.ExtendedArpEvent{
+				newArpReply("10.0.0.1", macA, startTs),
+				newArpRequest("10.0.0.2", macB, "10.0.0.1", startTs+1000),
+			},
+			"", "", "",
+		},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			detector := newIpConflictDetector(60)
+
+			var alerts []event.Alert
+			for _, p := range d.packets {
+				alerts = append(alerts, detector.Detect(p)...)
+			}
+
+			if d.expectedType == "" {
+				if len(alerts) != 0 {
+					t.Fatal("unexpected alerts:", alerts)
+				}
+				return
+			}
+
+			if len(alerts) != 1 {
+				t.Fatal("expected a single alert, got:", alerts)
+			}
+			notification := alerts[0].Notification
+			if alerts[0].Type != event.IpConflict || notification.EventType != "IP_CONFLICT" || notification.Ip != "10.0.0.1" {
+				t.Fatalf("unexpected alert: %v", notification)
+			}
+			if notification.ConflictType != d.expectedType || notification.Mac != d.expectedMac || notification.ConflictingMac != d.expectedConflict {
+				t.Fatalf("unexpected alert details: %v", notification)
+			}
+		})
+	}
+}
+
+func Test_IpConflictDetectorReportedOnce(t *testing.T) {
+	t.Parallel()
+
+	detector := newIpConflictDetector(60)
+	startTs := int64(1749913040000)
+	macA, macB := "00:00:00:00:00:0a", "00:00:00:00:00:0b"
+
+	detector.Detect(newArpReply("10.0.0.1", macA, startTs))
+	var alerts []event.Alert
+	for i := range 3 {
+		alerts = append(alerts, detector.Detect(newArpRequest("0.0.0.0", macB, "10.0.0.1", startTs+int64(i+1)*1000))...)
+	}
+	if len(alerts) != 1 {
+		t.Fatal("expected a single alert, got:", alerts)
+	}
+}
@@ -0,0 +1,24 @@
+package event
+
+// Detector looks for anomalies spanning multiple ARP packets or hosts, as opposed to the per-packet checks done by
+// ArpEventHandler itself. Detectors are called once for every ARP event, before the per-packet checks.
+type Detector interface {
+	Detect(extArpEvent ExtendedArpEvent) []Alert
+}
+
+// Suppressor is implemented by detectors which report an anomaly in place of some of the per-packet events, e.g. a
+// single IP_FLIP_FLOP event instead of a stream of NEW_MAC_FOR_IP events.
+type Suppressor interface {
+	Suppresses(extArpEvent ExtendedArpEvent, eventType Type) bool
+}
+
+// PeriodicDetector is implemented by detectors which also look for anomalies in the absence of ARP packets, e.g. hosts
+// not seen for too long. Check is called periodically, with the current Unix timestamp in milliseconds.
+type PeriodicDetector interface {
+	Check(ts int64) []Alert
+}
+
+type Alert struct {
+	Type         Type
+	Notification Notification
+}
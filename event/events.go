@@ -3,42 +3,77 @@ package event
 import "net"
 
 type ArpEvent struct {
-	Ip  net.IP
-	Mac net.HardwareAddr
-	Ts  int64
+	Ip        net.IP
+	Mac       net.HardwareAddr
+	Ts        int64
+	TargetIp  net.IP
+	Operation uint16
+	Interface string
+	// 802.1Q VLAN ID, 0 if not tagged
+	Vlan uint16
 }
 
 type ExtendedArpEvent struct {
 	ArpEvent
-	FirstTs   int64
-	Count     int
-	MacVendor string
+	FirstTs             int64
+	Count               int
+	MacVendor           string
+	LocallyAdministered bool
+	Multicast           bool
+	// previous randomized MAC of this IP, if merged into this host
+	CollapsedMac net.HardwareAddr
 }
 
 func (e ExtendedArpEvent) toPacketNotification(eventType Type, expectedCidrRange string, otherIps []string, otherMacs []string) Notification {
 	return Notification{
-		EventType:         eventType.describe(),
-		Ip:                e.Ip.String(),
-		Mac:               e.Mac.String(),
-		FirstTs:           e.FirstTs,
-		Ts:                e.Ts,
-		Count:             e.Count,
-		MacVendor:         e.MacVendor,
-		ExpectedCidrRange: expectedCidrRange,
-		OtherIps:          otherIps,
-		OtherMacs:         otherMacs,
+		EventType:           eventType.describe(),
+		Ip:                  e.Ip.String(),
+		Mac:                 e.Mac.String(),
+		TargetIp:            e.targetIp(),
+		FirstTs:             e.FirstTs,
+		Ts:                  e.Ts,
+		Count:               e.Count,
+		MacVendor:           e.MacVendor,
+		LocallyAdministered: e.LocallyAdministered,
+		Multicast:           e.Multicast,
+		ExpectedCidrRange:   expectedCidrRange,
+		OtherIps:            otherIps,
+		OtherMacs:           otherMacs,
 	}
 }
 
 func (e ExtendedArpEvent) toHostNotification(eventType Type, expectedCidrRange string, otherIps []string, otherMacs []string) Notification {
 	return Notification{
-		EventType:         eventType.describe(),
-		Ip:                e.Ip.String(),
-		Mac:               e.Mac.String(),
-		Ts:                e.Ts,
-		MacVendor:         e.MacVendor,
-		ExpectedCidrRange: expectedCidrRange,
-		OtherIps:          otherIps,
-		OtherMacs:         otherMacs,
+		EventType:           eventType.describe(),
+		Ip:                  e.Ip.String(),
+		Mac:                 e.Mac.String(),
+		TargetIp:            e.targetIp(),
+		Ts:                  e.Ts,
+		MacVendor:           e.MacVendor,
+		LocallyAdministered: e.LocallyAdministered,
+		Multicast:           e.Multicast,
+		ExpectedCidrRange:   expectedCidrRange,
+		OtherIps:            otherIps,
+		OtherMacs:           otherMacs,
 	}
 }
+
+func (e ExtendedArpEvent) toAlertNotification(eventType Type) Notification {
+	return Notification{
+		EventType:           eventType.describe(),
+		Ip:                  e.Ip.String(),
+		Mac:                 e.Mac.String(),
+		TargetIp:            e.targetIp(),
+		Ts:                  e.Ts,
+		MacVendor:           e.MacVendor,
+		LocallyAdministered: e.LocallyAdministered,
+		Multicast:           e.Multicast,
+	}
+}
+
+func (e ExtendedArpEvent) targetIp() string {
+	if e.TargetIp == nil {
+		return ""
+	}
+	return e.TargetIp.String()
+}
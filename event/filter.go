@@ -4,96 +4,325 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"net"
+	"net/netip"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/ipastusi/netreact/oui"
 )
 
 type ArpEventFilter struct {
-	excludedIPs   map[string]struct{}
-	excludedMACs  map[string]struct{}
-	excludedPairs map[string]struct{}
+	excluded []AddressList
+	included []AddressList
+}
+
+func NewArpEventFilter(excluded ...AddressList) ArpEventFilter {
+	return ArpEventFilter{excluded: excluded}
+}
+
+// WithIncluded restricts the filter to the hosts matching any of the included lists, so that all the others are
+// excluded. Unlike an empty exclusion list, an empty inclusion list excludes everything.
+func (f ArpEventFilter) WithIncluded(included ...AddressList) ArpEventFilter {
+	f.included = append(slices.Clone(f.included), included...)
+	return f
 }
 
-func NewArpEventFilter(excludedIPs map[string]struct{}, excludedMACs map[string]struct{}, excludedPairs map[string]struct{}) ArpEventFilter {
-	return ArpEventFilter{
-		excludedIPs:   excludedIPs,
-		excludedMACs:  excludedMACs,
-		excludedPairs: excludedPairs,
+// IsExcluded reports whether the IP, the MAC or the pair of both matches any of the unexpired exclusions at ts, or
+// doesn't match any of the unexpired inclusions, if there are any inclusion lists
+func (f ArpEventFilter) IsExcluded(ip net.IP, mac net.HardwareAddr, ts int64) bool {
+	for _, list := range f.excluded {
+		if list.Matches(ip, mac, ts) {
+			return true
+		}
+	}
+	for _, list := range f.included {
+		if list.Matches(ip, mac, ts) {
+			return false
+		}
 	}
+	return len(f.included) > 0
 }
 
-func (f ArpEventFilter) IsExcluded(ip string, mac string) bool {
-	pair := fmt.Sprintf("%v,%v", ip, mac)
-	if _, ok := f.excludedIPs[ip]; ok {
-		return true
-	} else if _, ok = f.excludedMACs[mac]; ok {
-		return true
-	} else if _, ok = f.excludedPairs[pair]; ok {
+// EventFilter drops the events of the hosts excluded by the host filter, except for the exempt event types. Unlike
+// ArpEventFilter applied to the packets, it leaves the hosts tracked.
+type EventFilter struct {
+	hosts       ArpEventFilter
+	exemptTypes []Type
+}
+
+func NewEventFilter(hosts ArpEventFilter, exemptTypes ...Type) EventFilter {
+	return EventFilter{hosts: hosts, exemptTypes: exemptTypes}
+}
+
+// IsExcluded reports whether the event of the type, reported for the IP and the MAC, should be dropped
+func (f EventFilter) IsExcluded(ip net.IP, mac net.HardwareAddr, ts int64, eventType Type) bool {
+	return !slices.Contains(f.exemptTypes, eventType) && f.hosts.IsExcluded(ip, mac, ts)
+}
+
+// AddressList holds IPv4 addresses and CIDR ranges, MAC addresses and prefixes, and pairs of both, each optionally
+// expiring. Entries are grouped by the prefix length, so matching takes a map lookup per distinct prefix length rather
+// than a scan of all the entries.
+type AddressList struct {
+	ips   ipSet
+	macs  macSet
+	pairs map[int]map[netip.Prefix]*macSet
+	// pair IP prefix lengths, longest first
+	pairLengths []int
+	size        int
+}
+
+// ipSet maps the CIDR ranges, exact IPs being /32, to their expiry timestamps, grouped by the prefix length
+type ipSet struct {
+	prefixes map[int]map[netip.Prefix]int64
+	lengths  []int
+}
+
+// macSet maps the MAC prefixes, exact MACs being 6 bytes long, to their expiry timestamps, grouped by the prefix length
+type macSet struct {
+	prefixes map[int]map[string]int64
+	lengths  []int
+}
+
+// never expiring entries
+const noExpiry = math.MaxInt64
+
+func (l AddressList) Len() int {
+	return l.size
+}
+
+// Matches reports whether the IP, the MAC or the pair of both matches any of the entries unexpired at ts. A missing IP
+// only matches by the MAC, and the other way round.
+func (l AddressList) Matches(ip net.IP, mac net.HardwareAddr, ts int64) bool {
+	// the zero netip.Addr of a missing IP matches none of the prefixes
+	addr, _ := netip.AddrFromSlice(ip)
+	addr = addr.Unmap()
+	if l.ips.matches(addr, ts) || l.macs.matches(mac, ts) {
 		return true
 	}
+	for _, bits := range l.pairLengths {
+		if !addr.Is4() {
+			break
+		}
+		prefix, _ := addr.Prefix(bits)
+		if macs, ok := l.pairs[bits][prefix]; ok && macs.matches(mac, ts) {
+			return true
+		}
+	}
 	return false
 }
 
-func ReadIPs(reader io.Reader) (map[string]struct{}, error) {
-	ips := map[string]struct{}{}
+func (s *ipSet) add(prefix netip.Prefix, expiresTs int64) {
+	if s.prefixes == nil {
+		s.prefixes = map[int]map[netip.Prefix]int64{}
+	}
+	bits := prefix.Bits()
+	if _, ok := s.prefixes[bits]; !ok {
+		s.prefixes[bits] = map[netip.Prefix]int64{}
+		s.lengths = insertLength(s.lengths, bits)
+	}
+	s.prefixes[bits][prefix] = max(s.prefixes[bits][prefix], expiresTs)
+}
 
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmedLine := strings.Trim(line, " ")
-		trimmedLine = strings.TrimRight(trimmedLine, "\r\n")
-		if !IsValidIPv4(trimmedLine) {
-			return nil, fmt.Errorf("invalid IP address: %v", line)
+func (s *ipSet) matches(addr netip.Addr, ts int64) bool {
+	if !addr.Is4() {
+		return false
+	}
+	for _, bits := range s.lengths {
+		prefix, _ := addr.Prefix(bits)
+		if expiresTs, ok := s.prefixes[bits][prefix]; ok && ts <= expiresTs {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *macSet) add(prefix []byte, expiresTs int64) {
+	if s.prefixes == nil {
+		s.prefixes = map[int]map[string]int64{}
+	}
+	bits := len(prefix)
+	if _, ok := s.prefixes[bits]; !ok {
+		s.prefixes[bits] = map[string]int64{}
+		s.lengths = insertLength(s.lengths, bits)
+	}
+	s.prefixes[bits][string(prefix)] = max(s.prefixes[bits][string(prefix)], expiresTs)
+}
+
+func (s *macSet) matches(mac net.HardwareAddr, ts int64) bool {
+	if len(mac) == 0 {
+		return false
+	}
+	for _, length := range s.lengths {
+		if length > len(mac) {
+			continue
+		}
+		if expiresTs, ok := s.prefixes[length][string(mac[:length])]; ok && ts <= expiresTs {
+			return true
 		}
-		ips[trimmedLine] = struct{}{}
 	}
+	return false
+}
 
-	return ips, nil
+// insertLength keeps the prefix lengths sorted, longest first
+func insertLength(lengths []int, length int) []int {
+	i, _ := slices.BinarySearchFunc(lengths, length, func(a, b int) int { return b - a })
+	return slices.Insert(lengths, i, length)
 }
 
-func ReadMACs(reader io.Reader) (map[string]struct{}, error) {
-	macs := map[string]struct{}{}
+func (l *AddressList) addPair(ipPrefix netip.Prefix, macPrefix []byte, expiresTs int64) {
+	if l.pairs == nil {
+		l.pairs = map[int]map[netip.Prefix]*macSet{}
+	}
+	bits := ipPrefix.Bits()
+	if _, ok := l.pairs[bits]; !ok {
+		l.pairs[bits] = map[netip.Prefix]*macSet{}
+		l.pairLengths = insertLength(l.pairLengths, bits)
+	}
+	if _, ok := l.pairs[bits][ipPrefix]; !ok {
+		l.pairs[bits][ipPrefix] = &macSet{}
+	}
+	l.pairs[bits][ipPrefix].add(macPrefix, expiresTs)
+}
 
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmedLine := strings.Trim(line, " ")
-		trimmedLine = strings.TrimRight(trimmedLine, "\r\n")
-		if !IsValidMAC(trimmedLine) {
-			return nil, fmt.Errorf("invalid MAC address: %v", line)
+// ReadIPs reads IPv4 addresses or CIDR ranges, e.g. 10.0.0.1 or 10.0.0.0/24, one per line
+func ReadIPs(reader io.Reader) (AddressList, error) {
+	var list AddressList
+	err := readEntries(reader, func(entry string, expiresTs int64) error {
+		prefix, err := parseIpPattern(entry)
+		if err != nil {
+			return err
 		}
-		macs[trimmedLine] = struct{}{}
+		list.ips.add(prefix, expiresTs)
+		list.size++
+		return nil
+	})
+	if err != nil {
+		return AddressList{}, err
 	}
+	return list, nil
+}
 
-	return macs, nil
+// ReadMACs reads MAC addresses or prefixes, e.g. b4:b6:86:01:02:03 or b4:b6:86:*, one per line
+func ReadMACs(reader io.Reader) (AddressList, error) {
+	var list AddressList
+	err := readEntries(reader, func(entry string, expiresTs int64) error {
+		prefix, err := parseMacPattern(entry)
+		if err != nil {
+			return err
+		}
+		list.macs.add(prefix, expiresTs)
+		list.size++
+		return nil
+	})
+	if err != nil {
+		return AddressList{}, err
+	}
+	return list, nil
 }
 
-func ReadPairs(reader io.Reader) (map[string]struct{}, error) {
-	pairs := map[string]struct{}{}
+// ReadPairs reads IP-MAC pairs, one per line, with the IP and the MAC in any of the forms accepted by ReadIPs and
+// ReadMACs, or * for any, e.g. 10.0.0.1,b4:b6:86:01:02:03 or 10.0.0.0/24,b4:b6:86:*
+func ReadPairs(reader io.Reader) (AddressList, error) {
+	var list AddressList
+	err := readEntries(reader, func(entry string, expiresTs int64) error {
+		ipPattern, macPattern, ok := strings.Cut(entry, ",")
+		if !ok {
+			return fmt.Errorf("invalid IP-MAC pair: %v", entry)
+		}
+		ipPrefix, err := parseIpPattern(ipPattern)
+		if err != nil {
+			return err
+		}
+		macPrefix, err := parseMacPattern(macPattern)
+		if err != nil {
+			return err
+		}
+		list.addPair(ipPrefix, macPrefix, expiresTs)
+		list.size++
+		return nil
+	})
+	if err != nil {
+		return AddressList{}, err
+	}
+	return list, nil
+}
 
+// readEntries calls add for every entry, i.e. every line with the comments starting with # and surrounding whitespace
+// removed, skipping blank lines. An entry can be followed by its expiry, e.g. 10.0.0.1 expires=2025-12-31, after which
+// it no longer matches.
+func readEntries(reader io.Reader, add func(entry string, expiresTs int64) error) error {
 	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmedLine := strings.Trim(line, " ")
-		trimmedLine = strings.TrimRight(trimmedLine, "\r\n")
-
-		parts := strings.Split(trimmedLine, ",")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid line: %v", line)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
 		}
 
-		ip, mac := parts[0], parts[1]
-		if !IsValidIPv4(ip) {
-			return nil, fmt.Errorf("invalid IP address: %v", line)
-		} else if !IsValidMAC(mac) {
-			return nil, fmt.Errorf("invalid MAC address: %v", line)
+		expiresTs := int64(noExpiry)
+		for _, field := range fields[1:] {
+			value, ok := strings.CutPrefix(field, "expires=")
+			if !ok {
+				return fmt.Errorf("invalid line %v: %v", lineNo, scanner.Text())
+			}
+			var err error
+			if expiresTs, err = parseExpiry(value); err != nil {
+				return fmt.Errorf("invalid line %v: %w", lineNo, err)
+			}
 		}
+		if err := add(fields[0], expiresTs); err != nil {
+			return fmt.Errorf("invalid line %v: %w", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
 
-		pair := fmt.Sprintf("%v,%v", ip, mac)
-		pairs[pair] = struct{}{}
+// parseExpiry parses a date, expiring at the end of that day, or an RFC 3339 timestamp
+func parseExpiry(value string) (int64, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return date.AddDate(0, 0, 1).UnixMilli() - 1, nil
 	}
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expiry %v, expected a date or an RFC 3339 timestamp", value)
+	}
+	return ts.UnixMilli(), nil
+}
 
-	return pairs, nil
+func parseIpPattern(pattern string) (netip.Prefix, error) {
+	if pattern == "*" {
+		return netip.PrefixFrom(netip.IPv4Unspecified(), 0), nil
+	}
+	if strings.Contains(pattern, "/") {
+		prefix, err := netip.ParsePrefix(pattern)
+		if err != nil || !prefix.Addr().Is4() {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR range: %v", pattern)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(pattern)
+	if err != nil || !addr.Is4() {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address: %v", pattern)
+	}
+	return netip.PrefixFrom(addr, 32), nil
+}
+
+func parseMacPattern(pattern string) ([]byte, error) {
+	if pattern == "*" {
+		return []byte{}, nil
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		prefix = strings.TrimRight(prefix, ":-.")
+		return oui.ParsePrefix(prefix)
+	}
+	mac, err := net.ParseMAC(pattern)
+	if err != nil || len(mac) != 6 {
+		return nil, fmt.Errorf("invalid MAC address: %v", pattern)
+	}
+	return mac, nil
 }
 
 func IsValidIPv4(ip string) bool {
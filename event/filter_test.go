@@ -2,6 +2,7 @@ package event_test
 
 import (
 	"io"
+	"net"
 	"strings"
 	"testing"
 
@@ -11,41 +12,67 @@ import (
 func Test_isExcluded(t *testing.T) {
 	t.Parallel()
 
-	excludedIPs := map[string]struct{}{
-		"10.0.0.2": {},
-		"10.0.0.3": {},
+	excludedIPs, err := event.ReadIPs(strings.NewReader(`
+10.0.0.2
+10.0.0.3
+# a range, expired at the end of 2025
+10.0.1.0/24 expires=2025-12-31
+10.0.3.0/24`))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
 	}
 
-	excludedMACs := map[string]struct{}{
-		"31:0c:8a:cb:8f:aa": {},
-		"31:0c:8a:cb:8f:ab": {},
-		"31:0c:8a:cb:8f:ac": {},
+	excludedMACs, err := event.ReadMACs(strings.NewReader(`
+31:0c:8a:cb:8f:aa
+31:0c:8a:cb:8f:ab # inline comment
+31:0c:8a:cb:8f:ac
+b4:b6:86:*`))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
 	}
 
-	excludedPairs := map[string]struct{}{
-		"10.0.2.1,31:0c:8a:cb:0a:0a": {},
-		"10.0.2.2,31:0c:8a:cb:0b:0b": {},
+	excludedPairs, err := event.ReadPairs(strings.NewReader(`
+10.0.2.1,31:0c:8a:cb:0a:0a
+10.0.2.2,31:0c:8a:cb:0b:0b
+10.0.4.0/24,f8:bc:12:*
+*,00:00:5e:00:01:01 expires=2025-12-31T12:00:00Z`))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
 	}
 
 	filter := event.NewArpEventFilter(excludedIPs, excludedMACs, excludedPairs)
+	// 2025-12-30T12:00:00Z
+	beforeExpiry := int64(1767096000000)
+	// 2026-01-02T00:00:00Z
+	afterExpiry := int64(1767312000000)
 
 	data := map[string]struct {
 		mac           string
 		ip            string
+		ts            int64
 		shouldExclude bool
 	}{
-		"ok":                     {"31:0c:8a:cb:8f:00", "10.0.0.1", false},
-		"excluded ip part only":  {"31:0c:8a:cb:8f:00", "10.0.2.1", false},
-		"excluded mac part only": {"31:0c:8a:cb:0b:0b", "10.0.0.1", false},
-		"excluded ip":            {"31:0c:8a:cb:8f:00", "10.0.0.2", true},
-		"excluded mac":           {"31:0c:8a:cb:8f:aa", "10.0.0.1", true},
-		"excluded pair":          {"31:0c:8a:cb:0a:0a", "10.0.2.1", true},
+		"ok":                        {"31:0c:8a:cb:8f:00", "10.0.0.1", beforeExpiry, false},
+		"excluded ip part only":     {"31:0c:8a:cb:8f:00", "10.0.2.1", beforeExpiry, false},
+		"excluded mac part only":    {"31:0c:8a:cb:0b:0b", "10.0.0.1", beforeExpiry, false},
+		"excluded ip":               {"31:0c:8a:cb:8f:00", "10.0.0.2", beforeExpiry, true},
+		"excluded mac":              {"31:0c:8a:cb:8f:aa", "10.0.0.1", beforeExpiry, true},
+		"excluded pair":             {"31:0c:8a:cb:0a:0a", "10.0.2.1", beforeExpiry, true},
+		"excluded range":            {"31:0c:8a:cb:8f:00", "10.0.3.200", afterExpiry, true},
+		"excluded expiring range":   {"31:0c:8a:cb:8f:00", "10.0.1.200", beforeExpiry, true},
+		"expired range":             {"31:0c:8a:cb:8f:00", "10.0.1.200", afterExpiry, false},
+		"excluded mac prefix":       {"b4:b6:86:01:02:03", "10.0.0.1", beforeExpiry, true},
+		"excluded range and prefix": {"f8:bc:12:01:02:03", "10.0.4.10", beforeExpiry, true},
+		"prefix out of pair range":  {"f8:bc:12:01:02:03", "10.0.5.10", beforeExpiry, false},
+		"excluded any ip pair":      {"00:00:5e:00:01:01", "192.168.1.1", beforeExpiry, true},
+		"expired any ip pair":       {"00:00:5e:00:01:01", "192.168.1.1", afterExpiry, false},
 	}
 
 	for name, d := range data {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			isExcluded := filter.IsExcluded(d.ip, d.mac)
+			mac, _ := net.ParseMAC(d.mac)
+			isExcluded := filter.IsExcluded(net.ParseIP(d.ip), mac, d.ts)
 			if isExcluded != d.shouldExclude {
 				t.Fatalf("unexpected result for IP %v MAC %v, expected ok: %v, got: %v", d.ip, d.mac, d.shouldExclude, isExcluded)
 			}
@@ -53,6 +80,75 @@ func Test_isExcluded(t *testing.T) {
 	}
 }
 
+func Test_isIncluded(t *testing.T) {
+	t.Parallel()
+
+	includedIPs, _ := event.ReadIPs(strings.NewReader("10.0.0.0/24"))
+	includedMACs, _ := event.ReadMACs(strings.NewReader("b4:b6:86:*"))
+	excludedIPs, _ := event.ReadIPs(strings.NewReader("10.0.0.1"))
+	filter := event.NewArpEventFilter(excludedIPs).WithIncluded(includedIPs, includedMACs)
+	emptyInclusion := event.NewArpEventFilter().WithIncluded(event.AddressList{})
+
+	data := map[string]struct {
+		filter        event.ArpEventFilter
+		mac           string
+		ip            string
+		shouldExclude bool
+	}{
+		"included ip":                {filter, "31:0c:8a:cb:8f:00", "10.0.0.2", false},
+		"included mac":               {filter, "b4:b6:86:01:02:03", "192.168.1.1", false},
+		"not included":               {filter, "31:0c:8a:cb:8f:00", "192.168.1.1", true},
+		"included, but excluded":     {filter, "b4:b6:86:01:02:03", "10.0.0.1", true},
+		"empty inclusion":            {emptyInclusion, "b4:b6:86:01:02:03", "10.0.0.2", true},
+		"no inclusion nor exclusion": {event.NewArpEventFilter(), "b4:b6:86:01:02:03", "10.0.0.2", false},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mac, _ := net.ParseMAC(d.mac)
+			isExcluded := d.filter.IsExcluded(net.ParseIP(d.ip), mac, 0)
+			if isExcluded != d.shouldExclude {
+				t.Fatalf("unexpected result for IP %v MAC %v, expected ok: %v, got: %v", d.ip, d.mac, d.shouldExclude, isExcluded)
+			}
+		})
+	}
+}
+
+func Test_eventFilter(t *testing.T) {
+	t.Parallel()
+
+	excludedMACs, _ := event.ReadMACs(strings.NewReader("b4:b6:86:01:02:03"))
+	filter := event.NewEventFilter(event.NewArpEventFilter(excludedMACs), event.NewHost, event.BindingViolation)
+	mac, _ := net.ParseMAC("b4:b6:86:01:02:03")
+	otherMac, _ := net.ParseMAC("b4:b6:86:01:02:04")
+
+	data := map[string]struct {
+		mac           net.HardwareAddr
+		ip            net.IP
+		eventType     event.Type
+		shouldExclude bool
+	}{
+		"excluded":            {mac, net.ParseIP("10.0.0.1"), event.NewPacket, true},
+		"excluded, no ip":     {mac, nil, event.IpConflict, true},
+		"exempt type":         {mac, net.ParseIP("10.0.0.1"), event.BindingViolation, false},
+		"not excluded":        {otherMac, net.ParseIP("10.0.0.1"), event.NewPacket, false},
+		"no ip nor mac":       {nil, nil, event.ArpFloodGlobal, false},
+		"exempt, no ip":       {mac, nil, event.NewHost, false},
+		"not excluded, no ip": {otherMac, nil, event.IpConflict, false},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			isExcluded := filter.IsExcluded(d.ip, d.mac, 0, d.eventType)
+			if isExcluded != d.shouldExclude {
+				t.Fatalf("unexpected result for IP %v MAC %v type %v, expected ok: %v, got: %v", d.ip, d.mac, d.eventType, d.shouldExclude, isExcluded)
+			}
+		})
+	}
+}
+
 func Test_readIPs(t *testing.T) {
 	t.Parallel()
 
@@ -65,6 +161,11 @@ func Test_readIPs(t *testing.T) {
 		"two":                        {strings.NewReader(" 10.0.0.1\r\n10.0.0.2 "), 2, true},
 		"two with trailing new line": {strings.NewReader("10.0.0.1\n10.0.0.2\n"), 2, true},
 		"invalid":                    {strings.NewReader("10.0.0.1\n10.0.0.2\ninvalid"), 0, false},
+		"comments and blank lines":   {strings.NewReader("# gateways\n\n10.0.0.1 # primary\n  \n10.0.0.2\n"), 2, true},
+		"cidr range":                 {strings.NewReader("10.0.0.0/24 expires=2025-12-31"), 1, true},
+		"ipv6 range":                 {strings.NewReader("2001:db8::/32"), 0, false},
+		"invalid expiry":             {strings.NewReader("10.0.0.1 expires=tomorrow"), 0, false},
+		"unexpected field":           {strings.NewReader("10.0.0.1 2025-12-31"), 0, false},
 	}
 
 	for name, d := range data {
@@ -74,8 +175,8 @@ func Test_readIPs(t *testing.T) {
 			if (err == nil && !d.ok) || (err != nil && d.ok) {
 				t.Fatalf("unexpected result for data %v, expected ok: %v, got error: %v", d.data, d.ok, err)
 			}
-			if len(ips) != d.size {
-				t.Fatalf("unexpected size for data %v, expected ok: %v, got: %v", d.data, d.size, len(ips))
+			if ips.Len() != d.size {
+				t.Fatalf("unexpected size for data %v, expected ok: %v, got: %v", d.data, d.size, ips.Len())
 			}
 		})
 	}
@@ -93,6 +194,9 @@ func Test_readMACs(t *testing.T) {
 		"two":                        {strings.NewReader(" 00:00:00:00:00:01\r\n00:00:00:00:00:02 "), 2, true},
 		"two with trailing new line": {strings.NewReader("00:00:00:00:00:01\n00:00:00:00:00:02\n"), 2, true},
 		"invalid":                    {strings.NewReader("00:00:00:00:00:01\n00:00:00:00:00:02\ninvalid"), 0, false},
+		"prefixes":                   {strings.NewReader("b4:b6:86:*\nb4-b6-86-01-*\n*"), 3, true},
+		"invalid prefix":             {strings.NewReader("b4:b6:8x:*"), 0, false},
+		"eui-64":                     {strings.NewReader("00:00:00:00:00:00:00:01"), 0, false},
 	}
 
 	for name, d := range data {
@@ -102,8 +206,8 @@ func Test_readMACs(t *testing.T) {
 			if (err == nil && !d.ok) || (err != nil && d.ok) {
 				t.Fatalf("unexpected result for data %v, expected ok: %v, got error: %v", d.data, d.ok, err)
 			}
-			if len(ips) != d.size {
-				t.Fatalf("unexpected size for data %v, expected ok: %v, got: %v", d.data, d.size, len(ips))
+			if ips.Len() != d.size {
+				t.Fatalf("unexpected size for data %v, expected ok: %v, got: %v", d.data, d.size, ips.Len())
 			}
 		})
 	}
@@ -121,6 +225,8 @@ func Test_readPairs(t *testing.T) {
 		"two":                        {strings.NewReader(" 10.0.0.1,00:00:00:00:00:01\r\n10.0.0.2,00:00:00:00:00:02 "), 2, true},
 		"two with trailing new line": {strings.NewReader("10.0.0.1,00:00:00:00:00:01\n10.0.0.2,00:00:00:00:00:02\n"), 2, true},
 		"invalid":                    {strings.NewReader("10.0.0.1,00:00:00:00:00:01\n10.0.0.2,00:00:00:00:00:02\ninvalid"), 0, false},
+		"ranges and prefixes":        {strings.NewReader("10.0.0.0/24,b4:b6:86:*\n*,00:00:00:00:00:01\n10.0.0.1,*"), 3, true},
+		"invalid range":              {strings.NewReader("10.0.0.0/33,b4:b6:86:*"), 0, false},
 	}
 
 	for name, d := range data {
@@ -130,8 +236,8 @@ func Test_readPairs(t *testing.T) {
 			if (err == nil && !d.ok) || (err != nil && d.ok) {
 				t.Fatalf("unexpected result for data %v, expected ok: %v, got error: %v", d.data, d.ok, err)
 			}
-			if len(ips) != d.size {
-				t.Fatalf("unexpected size for data %v, expected ok: %v, got: %v", d.data, d.size, len(ips))
+			if ips.Len() != d.size {
+				t.Fatalf("unexpected size for data %v, expected ok: %v, got: %v", d.data, d.size, ips.Len())
 			}
 		})
 	}
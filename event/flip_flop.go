@@ -0,0 +1,78 @@
+package event
+
+import (
+	"github.com/ipastusi/netreact/config"
+)
+
+type ownerTracker struct {
+	// ordered MAC owners, the first one being the owner before the oldest flip within the window
+	history  []OwnerChange
+	flipping bool
+}
+
+type IpFlipFlopDetector struct {
+	windowMs   int64
+	minFlips   int
+	maxHistory int
+	ips        map[string]*ownerTracker
+}
+
+func NewIpFlipFlopDetector(cfg config.IpFlipFlopConfig) *IpFlipFlopDetector {
+	minFlips := int(*cfg.MinFlips)
+	return &IpFlipFlopDetector{
+		windowMs:   int64(*cfg.WindowSec) * 1000,
+		minFlips:   minFlips,
+		maxHistory: max(64, minFlips+1),
+		ips:        map[string]*ownerTracker{},
+	}
+}
+
+func (d *IpFlipFlopDetector) Detect(extArpEvent ExtendedArpEvent) []Alert {
+	if extArpEvent.Ip.IsUnspecified() {
+		// ARP probes
+		return nil
+	}
+
+	ip, mac, ts := extArpEvent.Ip.String(), extArpEvent.Mac.String(), extArpEvent.Ts
+	tracker, ok := d.ips[ip]
+	if !ok {
+		d.ips[ip] = &ownerTracker{
+			history: []OwnerChange{{Mac: mac, Ts: ts}},
+		}
+		return nil
+	}
+
+	if tracker.history[len(tracker.history)-1].Mac != mac {
+		tracker.history = append(tracker.history, OwnerChange{Mac: mac, Ts: ts})
+	}
+	for len(tracker.history) > 1 && (ts-tracker.history[1].Ts > d.windowMs || len(tracker.history) > d.maxHistory) {
+		tracker.history = tracker.history[1:]
+	}
+
+	flips := len(tracker.history) - 1
+	if flips < d.minFlips {
+		tracker.flipping = false
+		return nil
+	} else if tracker.flipping {
+		// already reported
+		return nil
+	}
+
+	tracker.flipping = true
+	notification := extArpEvent.toAlertNotification(IpFlipFlop)
+	notification.FlipCount = flips
+	notification.FlipIntervalMs = (ts - tracker.history[1].Ts) / int64(flips-1)
+	notification.OwnerHistory = append([]OwnerChange(nil), tracker.history...)
+	notification.WindowSec = uint(d.windowMs / 1000)
+	return []Alert{{Type: IpFlipFlop, Notification: notification}}
+}
+
+// Suppresses NEW_MAC_FOR_IP events for the IP addresses which are currently flip-flopping, as these are already
+// reported as IP_FLIP_FLOP
+func (d *IpFlipFlopDetector) Suppresses(extArpEvent ExtendedArpEvent, eventType Type) bool {
+	if eventType != NewMacForIpPacket && eventType != NewMacForIpHost {
+		return false
+	}
+	tracker, ok := d.ips[extArpEvent.Ip.String()]
+	return ok && tracker.flipping
+}
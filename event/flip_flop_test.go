@@ -0,0 +1,105 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ipastusi/netreact/config"
+	"github.com/ipastusi/netreact/event"
+)
+
+func newIpFlipFlopDetector(windowSec uint, minFlips uint) *event.IpFlipFlopDetector {
+	enabled := true
+	return event.NewIpFlipFlopDetector(config.IpFlipFlopConfig{
+		Enabled:   &enabled,
+		WindowSec: &windowSec,
+		MinFlips:  &minFlips,
+	})
+}
+
+func Test_IpFlipFlopDetector(t *testing.T) {
+	t.Parallel()
+
+	detector := newIpFlipFlopDetector(60, 3)
+	startTs := int64(1749913040000)
+	macA, macB := "00:00:00:00:00:0a", "00:00:00:00:00:0b"
+
+	packets := []struct {
+		mac            string
+		ts             int64
+		expectedAlerts int
+	}{
+		{macA, startTs, 0},
+		{macA, startTs + 1000, 0},
+		{macB, startTs + 2000, 0},
+		{macA, startTs + 4000, 0},
+		{macA, startTs + 5000, 0},
+		{macB, startTs + 6000, 1},
+		// already reported
+		{macA, startTs + 8000, 0},
+	}
+
+	var alerts []event.Alert
+	for i, p := range packets {
+		e := newExtArpEvent("10.0.0.1", p.mac, p.ts)
+		newAlerts := detector.Detect(e)
+		if len(newAlerts) != p.expectedAlerts {
+			t.Fatalf("unexpected alerts for packet %v, expected: %v, got: %v", i, p.expectedAlerts, newAlerts)
+		}
+		alerts = append(alerts, newAlerts...)
+
+		suppressed := detector.Suppresses(e, event.NewMacForIpPacket)
+		if suppressed != (len(alerts) > 0) {
+			t.Fatalf("unexpected suppression for packet %v: %v", i, suppressed)
+		}
+	}
+
+	notification := alerts[0].Notification
+	if alerts[0].Type != event.IpFlipFlop || notification.EventType != "IP_FLIP_FLOP" {
+		t.Fatalf("unexpected alert: %v", notification)
+	}
+	if notification.FlipCount != 3 || notification.FlipIntervalMs != 2000 || notification.WindowSec != 60 {
+		t.Fatalf("unexpected alert details: %v", notification)
+	}
+	expectedHistory := []event.OwnerChange{
+		{Mac: macA, Ts: startTs},
+		{Mac: macB, Ts: startTs + 2000},
+		{Mac: macA, Ts: startTs + 4000},
+		{Mac: macB, Ts: startTs + 6000},
+	}
+	if diff := cmp.Diff(expectedHistory, notification.OwnerHistory); diff != "" {
+		t.Fatal("unexpected owner history:", diff)
+	}
+
+	// other IPs and event types are not suppressed
+	if detector.Suppresses(newExtArpEvent("10.0.0.2", macA, startTs+9000), event.NewMacForIpPacket) {
+		t.Fatal("unexpected suppression for another IP")
+	}
+	if detector.Suppresses(newExtArpEvent("10.0.0.1", macA, startTs+9000), event.NewIpForMacPacket) {
+		t.Fatal("unexpected suppression for another event type")
+	}
+}
+
+func Test_IpFlipFlopDetectorWindow(t *testing.T) {
+	t.Parallel()
+
+	detector := newIpFlipFlopDetector(10, 3)
+	startTs := int64(1749913040000)
+	macs := []string{"00:00:00:00:00:0a", "00:00:00:00:00:0b"}
+
+	// an occasional change of the owner is not a flip-flop
+	for i := range 10 {
+		alerts := detector.Detect(newExtArpEvent("10.0.0.1", macs[i%2], startTs+int64(i*6000)))
+		if len(alerts) != 0 {
+			t.Fatalf("unexpected alerts for packet %v: %v", i, alerts)
+		}
+	}
+
+	// neither are probes
+	for i := range 10 {
+		alerts := detector.Detect(newExtArpEvent("0.0.0.0", macs[i%2], startTs+int64(i)))
+		if len(alerts) != 0 {
+			t.Fatalf("unexpected alerts for probe %v: %v", i, alerts)
+		}
+	}
+}
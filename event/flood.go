@@ -0,0 +1,189 @@
+package event
+
+import (
+	"math"
+
+	"github.com/ipastusi/netreact/config"
+)
+
+// rateCounter counts packets over a sliding window, using one bucket per second
+type rateCounter struct {
+	buckets []rateBucket
+}
+
+type rateBucket struct {
+	sec   int64
+	count int
+}
+
+func newRateCounter(windowSec uint) *rateCounter {
+	return &rateCounter{
+		buckets: make([]rateBucket, windowSec),
+	}
+}
+
+func (r *rateCounter) add(ts int64) {
+	sec := ts / 1000
+	b := &r.buckets[sec%int64(len(r.buckets))]
+	if b.sec != sec {
+		b.sec, b.count = sec, 0
+	}
+	b.count++
+}
+
+// count returns the number of packets in the window ending with the second of ts
+func (r *rateCounter) count(ts int64) int {
+	sec := ts / 1000
+	windowSize := int64(len(r.buckets))
+	var total int
+	for _, b := range r.buckets {
+		if b.sec > sec-windowSize && b.sec <= sec {
+			total += b.count
+		}
+	}
+	return total
+}
+
+// rate returns the average number of packets per second in the window ending with the second of ts
+func (r *rateCounter) rate(ts int64) float64 {
+	return float64(r.count(ts)) / float64(len(r.buckets))
+}
+
+type hostRate struct {
+	counter  *rateCounter
+	lastTs   int64
+	flooding bool
+}
+
+type ArpFloodDetector struct {
+	windowSec    uint
+	hostMaxPps   float64
+	globalMinPps float64
+	spikeFactor  float64
+	learningMs   int64
+	alpha        float64
+	hosts        map[string]*hostRate
+	global       *rateCounter
+	startTs      int64
+	lastSec      int64
+	lastSweepSec int64
+	baseline     float64
+	learned      bool
+	flooding     bool
+}
+
+func NewArpFloodDetector(cfg config.ArpFloodConfig) *ArpFloodDetector {
+	learningSec := max(*cfg.LearningSec, 1)
+	return &ArpFloodDetector{
+		windowSec:    *cfg.WindowSec,
+		hostMaxPps:   float64(*cfg.HostMaxPps),
+		globalMinPps: float64(*cfg.GlobalMinPps),
+		spikeFactor:  *cfg.GlobalSpikeFactor,
+		learningMs:   int64(*cfg.LearningSec) * 1000,
+		// exponential moving average spanning roughly the learning period
+		alpha:  2 / (float64(learningSec) + 1),
+		hosts:  map[string]*hostRate{},
+		global: newRateCounter(*cfg.WindowSec),
+	}
+}
+
+func (d *ArpFloodDetector) Detect(extArpEvent ExtendedArpEvent) []Alert {
+	var alerts []Alert
+	ts := extArpEvent.Ts
+	if d.startTs == 0 {
+		d.startTs = ts
+	}
+
+	d.learn(ts)
+	d.global.add(ts)
+
+	mac := extArpEvent.Mac.String()
+	host, ok := d.hosts[mac]
+	if !ok {
+		host = &hostRate{counter: newRateCounter(d.windowSec)}
+		d.hosts[mac] = host
+	}
+	host.counter.add(ts)
+	host.lastTs = ts
+
+	hostPps := host.counter.rate(ts)
+	if hostPps > d.hostMaxPps {
+		if !host.flooding {
+			host.flooding = true
+			alerts = append(alerts, d.toAlert(extArpEvent, ArpFloodHost, hostPps, d.hostMaxPps))
+		}
+	} else {
+		host.flooding = false
+	}
+
+	globalPps := d.global.rate(ts)
+	globalMaxPps := d.spikeFactor * d.baseline
+	learning := ts-d.startTs < d.learningMs
+	if !learning && globalPps >= d.globalMinPps && globalPps > globalMaxPps {
+		if !d.flooding {
+			d.flooding = true
+			alerts = append(alerts, d.toAlert(extArpEvent, ArpFloodGlobal, globalPps, globalMaxPps))
+		}
+	} else {
+		d.flooding = false
+	}
+
+	return alerts
+}
+
+// learn updates the global baseline rate once per second, unless the global ARP volume is spiking at the moment
+func (d *ArpFloodDetector) learn(ts int64) {
+	sec := ts / 1000
+	if d.lastSec == 0 {
+		d.lastSec, d.lastSweepSec = sec, sec
+		return
+	} else if sec <= d.lastSec {
+		return
+	}
+
+	if !d.flooding {
+		rate := d.global.rate(d.lastSec * 1000)
+		if !d.learned {
+			d.baseline, d.learned = rate, true
+		} else {
+			d.baseline = d.alpha*rate + (1-d.alpha)*d.baseline
+		}
+		// seconds without any ARP traffic
+		idleSec := sec - d.lastSec - 1
+		d.baseline *= math.Pow(1-d.alpha, float64(idleSec))
+	}
+	d.lastSec = sec
+
+	if sec-d.lastSweepSec >= int64(d.windowSec) {
+		d.sweep(ts)
+		d.lastSweepSec = sec
+	}
+}
+
+// sweep forgets the hosts which have been quiet for the entire window
+func (d *ArpFloodDetector) sweep(ts int64) {
+	windowMs := int64(d.windowSec) * 1000
+	for mac, host := range d.hosts {
+		if ts-host.lastTs > windowMs {
+			delete(d.hosts, mac)
+		}
+	}
+}
+
+func (d *ArpFloodDetector) toAlert(extArpEvent ExtendedArpEvent, eventType Type, rate float64, threshold float64) Alert {
+	notification := extArpEvent.toAlertNotification(eventType)
+	notification.PacketRate = roundRate(rate)
+	notification.Threshold = roundRate(threshold)
+	notification.WindowSec = d.windowSec
+	if eventType == ArpFloodHost {
+		notification.Scope = "host"
+	} else {
+		notification.Scope = "global"
+		notification.BaselineRate = roundRate(d.baseline)
+	}
+	return Alert{Type: eventType, Notification: notification}
+}
+
+func roundRate(rate float64) float64 {
+	return math.Round(rate*100) / 100
+}
@@ -0,0 +1,134 @@
+package event_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ipastusi/netreact/config"
+	"github.com/ipastusi/netreact/event"
+)
+
+func newFloodConfig(windowSec uint, hostMaxPps uint, globalMinPps uint, spikeFactor float64, learningSec uint) config.ArpFloodConfig {
+	enabled := true
+	return config.ArpFloodConfig{
+		Enabled:           &enabled,
+		WindowSec:         &windowSec,
+		HostMaxPps:        &hostMaxPps,
+		GlobalMinPps:      &globalMinPps,
+		GlobalSpikeFactor: &spikeFactor,
+		LearningSec:       &learningSec,
+	}
+}
+
+func newExtArpEvent(ip string, mac string, ts int64) event.ExtendedArpEvent {
+	hwAddr, _ := net.ParseMAC(mac)
+	return event.ExtendedArpEvent{
+		ArpEvent: event.ArpEvent{
+			Ip:  net.ParseIP(ip),
+			Mac: hwAddr,
+			Ts:  ts,
+		},
+	}
+}
+
+func Test_ArpFloodDetectorHost(t *testing.T) {
+	t.Parallel()
+
+	detector := event.NewArpFloodDetector(newFloodConfig(2, 5, 1000, 4, 60))
+	startTs := int64(1749913040000)
+
+	// 10 packets within 2 seconds is exactly 5 pps, which is not above the threshold
+	for i := range 10 {
+		alerts := detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:01", startTs+int64(i*150)))
+		if len(alerts) != 0 {
+			t.Fatalf("unexpected alerts for packet %v: %v", i, alerts)
+		}
+	}
+
+	// a different host does not contribute to the rate
+	alerts := detector.Detect(newExtArpEvent("10.0.0.2", "00:00:00:00:00:02", startTs+1500))
+	if len(alerts) != 0 {
+		t.Fatal("unexpected alerts for a different host:", alerts)
+	}
+
+	alerts = detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:01", startTs+1600))
+	if len(alerts) != 1 {
+		t.Fatal("expected a single alert, got:", alerts)
+	}
+	notification := alerts[0].Notification
+	if alerts[0].Type != event.ArpFloodHost || notification.EventType != "ARP_FLOOD" || notification.Scope != "host" {
+		t.Fatalf("unexpected alert: %v", notification)
+	}
+	if notification.PacketRate != 5.5 || notification.Threshold != 5 || notification.WindowSec != 2 {
+		t.Fatalf("unexpected alert details: %v", notification)
+	}
+
+	// ongoing flood is reported only once
+	alerts = detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:01", startTs+1700))
+	if len(alerts) != 0 {
+		t.Fatal("unexpected repeated alert:", alerts)
+	}
+
+	// flood ended, next one gets reported again
+	alerts = detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:01", startTs+10000))
+	if len(alerts) != 0 {
+		t.Fatal("unexpected alerts after the flood:", alerts)
+	}
+	alerts = nil
+	for i := range 20 {
+		alerts = append(alerts, detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:01", startTs+10100+int64(i)))...)
+	}
+	if len(alerts) != 1 {
+		t.Fatal("expected a single alert for the second flood, got:", alerts)
+	}
+}
+
+func Test_ArpFloodDetectorGlobal(t *testing.T) {
+	t.Parallel()
+
+	detector := event.NewArpFloodDetector(newFloodConfig(1, 1000, 10, 4, 10))
+	startTs := int64(1749913040000)
+
+	// learn the baseline of 5 pps, spread over different hosts
+	for sec := range 20 {
+		for i := range 5 {
+			ts := startTs + int64(sec*1000+i*100)
+			alerts := detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:0"+string(rune('1'+i)), ts))
+			if len(alerts) != 0 {
+				t.Fatalf("unexpected alerts while learning, second %v: %v", sec, alerts)
+			}
+		}
+	}
+
+	// 5x the baseline
+	var alerts []event.Alert
+	spikeTs := startTs + 20000
+	for i := range 25 {
+		alerts = append(alerts, detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:0"+string(rune('1'+i%5)), spikeTs+int64(i*10)))...)
+	}
+	if len(alerts) != 1 {
+		t.Fatal("expected a single alert, got:", alerts)
+	}
+	notification := alerts[0].Notification
+	if alerts[0].Type != event.ArpFloodGlobal || notification.Scope != "global" {
+		t.Fatalf("unexpected alert: %v", notification)
+	}
+	if notification.BaselineRate != 5 || notification.Threshold != 20 || notification.PacketRate != 21 {
+		t.Fatalf("unexpected alert details: %v", notification)
+	}
+}
+
+func Test_ArpFloodDetectorLearning(t *testing.T) {
+	t.Parallel()
+
+	detector := event.NewArpFloodDetector(newFloodConfig(1, 1000, 10, 4, 60))
+	startTs := int64(1749913040000)
+
+	// high volume right from the start is not reported while still learning
+	for i := range 100 {
+		alerts := detector.Detect(newExtArpEvent("10.0.0.1", "00:00:00:00:00:01", startTs+int64(i)))
+		if len(alerts) != 0 {
+			t.Fatal("unexpected alerts while learning:", alerts)
+		}
+	}
+}
@@ -2,25 +2,34 @@ package event
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/ipastusi/netreact/config"
 	"github.com/ipastusi/netreact/oui"
 )
 
+const maxFileNameAttempts = 1000
+
 type ArpEventHandler struct {
 	logHandler        slog.Handler
+	logPackets        bool
 	eventDir          string
 	packetEventConfig config.EventTypeConfig
 	hostEventConfig   config.EventTypeConfig
 	expectedCidrRange *net.IPNet
 	ipToMac           map[string]map[string]struct{}
 	macToIp           map[string]map[string]struct{}
+	detectors         []Detector
+	sinks             []Sink
+	eventFilters      []EventFilter
+	randomizedMac     config.RandomizedMacConfig
 }
 
 func NewArpEventHandler(
@@ -35,6 +44,7 @@ func NewArpEventHandler(
 	_, cidrRange, _ := net.ParseCIDR(expectedCidrRange)
 	return ArpEventHandler{
 		logHandler:        logHandler,
+		logPackets:        true,
 		eventDir:          eventDir,
 		packetEventConfig: packetEventConfig,
 		hostEventConfig:   hostEventConfig,
@@ -44,19 +54,49 @@ func NewArpEventHandler(
 	}
 }
 
+func (h ArpEventHandler) WithDetectors(detectors ...Detector) ArpEventHandler {
+	h.detectors = append(slices.Clone(h.detectors), detectors...)
+	return h
+}
+
+func (h ArpEventHandler) WithSinks(sinks ...Sink) ArpEventHandler {
+	h.sinks = append(slices.Clone(h.sinks), sinks...)
+	return h
+}
+
+// WithEventFilters drops the events of the hosts excluded by any of the filters, the hosts are still tracked
+func (h ArpEventHandler) WithEventFilters(filters ...EventFilter) ArpEventHandler {
+	h.eventFilters = append(slices.Clone(h.eventFilters), filters...)
+	return h
+}
+
+// WithPacketLog enables or disables logging every ARP packet, events and errors are logged either way
+func (h ArpEventHandler) WithPacketLog(enabled bool) ArpEventHandler {
+	h.logPackets = enabled
+	return h
+}
+
+func (h ArpEventHandler) WithRandomizedMacConfig(randomizedMac config.RandomizedMacConfig) ArpEventHandler {
+	h.randomizedMac = randomizedMac
+	return h
+}
+
 func (h ArpEventHandler) Handle(extArpEvent *ExtendedArpEvent) {
-	h.handleLog(*extArpEvent)
 	h.updateMaps(*extArpEvent)
 	h.lookupMacVendor(extArpEvent)
+	h.lookupMacType(extArpEvent)
+	h.handleLog(*extArpEvent)
+	h.handleDetectors(*extArpEvent)
 	h.handleEventFiles(*extArpEvent)
 }
 
 func (h ArpEventHandler) handleLog(extArpEvent ExtendedArpEvent) {
-	if h.logHandler != nil {
+	if h.logHandler != nil && h.logPackets {
 		r := slog.NewRecord(time.UnixMilli(extArpEvent.Ts), slog.LevelInfo, "ARP packet received", 0)
 		r.AddAttrs(
 			slog.String("IP", extArpEvent.Ip.String()),
 			slog.String("MAC", extArpEvent.Mac.String()),
+			slog.String("Vendor", extArpEvent.MacVendor),
 		)
 		_ = h.logHandler.Handle(nil, r)
 	}
@@ -64,6 +104,9 @@ func (h ArpEventHandler) handleLog(extArpEvent ExtendedArpEvent) {
 
 func (h ArpEventHandler) updateMaps(extArpEvent ExtendedArpEvent) {
 	ip, mac := extArpEvent.Ip.String(), extArpEvent.Mac.String()
+	if extArpEvent.CollapsedMac != nil {
+		h.Forget(ip, extArpEvent.CollapsedMac.String())
+	}
 
 	if _, ok := h.ipToMac[ip]; !ok {
 		h.ipToMac[ip] = map[string]struct{}{}
@@ -76,15 +119,34 @@ func (h ArpEventHandler) updateMaps(extArpEvent ExtendedArpEvent) {
 	h.macToIp[mac][ip] = struct{}{}
 }
 
+// Forget removes the IP-MAC pair from the known pairs, e.g. once the host is pruned, so that it no longer counts as a
+// previous MAC for this IP, or a previous IP for this MAC
+func (h ArpEventHandler) Forget(ip string, mac string) {
+	delete(h.ipToMac[ip], mac)
+	if len(h.ipToMac[ip]) == 0 {
+		delete(h.ipToMac, ip)
+	}
+
+	delete(h.macToIp[mac], ip)
+	if len(h.macToIp[mac]) == 0 {
+		delete(h.macToIp, mac)
+	}
+}
+
 func (h ArpEventHandler) lookupMacVendor(extArpEvent *ExtendedArpEvent) {
 	extArpEvent.MacVendor = oui.MacToVendor(extArpEvent.Mac)
 }
 
+func (h ArpEventHandler) lookupMacType(extArpEvent *ExtendedArpEvent) {
+	extArpEvent.LocallyAdministered = oui.IsLocallyAdministered(extArpEvent.Mac)
+	extArpEvent.Multicast = oui.IsMulticast(extArpEvent.Mac)
+}
+
 func (h ArpEventHandler) handleEventFiles(extArpEvent ExtendedArpEvent) {
 	if *h.packetEventConfig.Any == true {
 		h.handlePacketNotification(extArpEvent, NewPacket)
 	}
-	if *h.packetEventConfig.Any == true && extArpEvent.Count == 1 {
+	if *h.packetEventConfig.Any == true && extArpEvent.Count == 1 && !h.isExcludedFromNewHost(extArpEvent) {
 		h.handleHostNotification(extArpEvent, NewHost)
 	}
 
@@ -128,6 +190,15 @@ func (h ArpEventHandler) handleEventFiles(extArpEvent ExtendedArpEvent) {
 		}
 	}
 
+	if extArpEvent.LocallyAdministered || extArpEvent.Multicast {
+		if *h.packetEventConfig.NewRandomizedMac == true {
+			h.handlePacketNotification(extArpEvent, NewRandomizedMacPacket)
+		}
+		if *h.hostEventConfig.NewRandomizedMac == true && extArpEvent.Count == 1 {
+			h.handleHostNotification(extArpEvent, NewRandomizedMacHost)
+		}
+	}
+
 	if len(h.macToIp[extArpEvent.Mac.String()]) > 1 {
 		if *h.packetEventConfig.NewIpForMac == true {
 			h.handlePacketNotification(extArpEvent, NewIpForMacPacket)
@@ -147,7 +218,15 @@ func (h ArpEventHandler) handleEventFiles(extArpEvent ExtendedArpEvent) {
 	}
 }
 
+func (h ArpEventHandler) isExcludedFromNewHost(extArpEvent ExtendedArpEvent) bool {
+	excludeRandomized := h.randomizedMac.ExcludeFromNewHost != nil && *h.randomizedMac.ExcludeFromNewHost
+	return excludeRandomized && extArpEvent.LocallyAdministered
+}
+
 func (h ArpEventHandler) handlePacketNotification(extArpEvent ExtendedArpEvent, eventType Type) {
+	if h.isSuppressed(extArpEvent, eventType) {
+		return
+	}
 	expectedCidrRange := h.expectedCidrRange.String()
 	otherIps, otherMacs := h.getOtherIps(extArpEvent), h.getOtherMacs(extArpEvent)
 	eventJson := extArpEvent.toPacketNotification(eventType, expectedCidrRange, otherIps, otherMacs)
@@ -155,12 +234,64 @@ func (h ArpEventHandler) handlePacketNotification(extArpEvent ExtendedArpEvent,
 }
 
 func (h ArpEventHandler) handleHostNotification(extArpEvent ExtendedArpEvent, eventType Type) {
+	if h.isSuppressed(extArpEvent, eventType) {
+		return
+	}
 	expectedCidrRange := h.expectedCidrRange.String()
 	otherIps, otherMacs := h.getOtherIps(extArpEvent), h.getOtherMacs(extArpEvent)
 	eventJson := extArpEvent.toHostNotification(eventType, expectedCidrRange, otherIps, otherMacs)
 	h.storeNotification(eventJson, eventType)
 }
 
+func (h ArpEventHandler) handleDetectors(extArpEvent ExtendedArpEvent) {
+	for _, detector := range h.detectors {
+		for _, alert := range detector.Detect(extArpEvent) {
+			h.handleAlert(alert)
+		}
+	}
+}
+
+// Tick runs the periodic checks of all the detectors implementing PeriodicDetector
+func (h ArpEventHandler) Tick(ts int64) {
+	for _, detector := range h.detectors {
+		if periodicDetector, ok := detector.(PeriodicDetector); ok {
+			for _, alert := range periodicDetector.Check(ts) {
+				h.handleAlert(alert)
+			}
+		}
+	}
+}
+
+func (h ArpEventHandler) handleAlert(alert Alert) {
+	alert.Notification.ExpectedCidrRange = h.expectedCidrRange.String()
+	h.storeNotification(alert.Notification, alert.Type)
+}
+
+func (h ArpEventHandler) isSuppressed(extArpEvent ExtendedArpEvent, eventType Type) bool {
+	for _, detector := range h.detectors {
+		if suppressor, ok := detector.(Suppressor); ok && suppressor.Suppresses(extArpEvent, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFiltered reports whether any of the event filters drops the event. Events not related to any host, e.g. the global
+// ARP flood, are never dropped.
+func (h ArpEventHandler) isFiltered(eventJson Notification, eventType Type) bool {
+	if eventJson.Ip == "" && eventJson.Mac == "" {
+		return false
+	}
+	ip := net.ParseIP(eventJson.Ip)
+	mac, _ := net.ParseMAC(eventJson.Mac)
+	for _, filter := range h.eventFilters {
+		if filter.IsExcluded(ip, mac, eventJson.Ts, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
 func (h ArpEventHandler) getOtherIps(extArpEvent ExtendedArpEvent) []string {
 	all := h.macToIp[extArpEvent.Mac.String()]
 	var other []string
@@ -184,17 +315,51 @@ func (h ArpEventHandler) getOtherMacs(extArpEvent ExtendedArpEvent) []string {
 }
 
 func (h ArpEventHandler) storeNotification(eventJson Notification, eventType Type) {
-	eventFileName := fmt.Sprintf("netreact-%v-%v.json", eventJson.Ts, eventType)
+	if h.isFiltered(eventJson, eventType) {
+		return
+	}
+	h.logEvent(eventJson)
 	eventBytes, err := json.Marshal(eventJson)
 	if err != nil {
 		h.logError(err)
 		return
 	}
-	eventFilePath := filepath.Join(h.eventDir, eventFileName)
-	err = syncWriteToFile(eventFilePath, eventBytes)
+
+	// events of the same type within the same millisecond, e.g. periodic checks reporting many hosts at once, get
+	// a counter suffix in their file names, so that the timestamp in the file name always matches the event
+	for attempt := 0; attempt < maxFileNameAttempts; attempt++ {
+		eventFileName := fmt.Sprintf("netreact-%v-%v.json", eventJson.Ts, eventType)
+		if attempt > 0 {
+			eventFileName = fmt.Sprintf("netreact-%v-%v-%v.json", eventJson.Ts, eventType, attempt)
+		}
+		eventFilePath := filepath.Join(h.eventDir, eventFileName)
+		err = syncWriteToFile(eventFilePath, eventBytes)
+		if !errors.Is(err, os.ErrExist) {
+			break
+		}
+	}
 	if err != nil {
 		h.logError(err)
 	}
+
+	for _, sink := range h.sinks {
+		if err = sink.StoreEvent(eventJson); err != nil {
+			h.logError(err)
+		}
+	}
+}
+
+func (h ArpEventHandler) logEvent(eventJson Notification) {
+	if h.logHandler != nil {
+		r := slog.NewRecord(time.UnixMilli(eventJson.Ts), slog.LevelInfo, "Event generated", 0)
+		r.AddAttrs(
+			slog.String("EventType", eventJson.EventType),
+			slog.String("IP", eventJson.Ip),
+			slog.String("MAC", eventJson.Mac),
+			slog.String("Vendor", eventJson.MacVendor),
+		)
+		_ = h.logHandler.Handle(nil, r)
+	}
 }
 
 func (h ArpEventHandler) logError(err error) {
@@ -205,7 +370,7 @@ func (h ArpEventHandler) logError(err error) {
 
 func syncWriteToFile(filename string, data []byte) error {
 	// put extra effort into making sure the events are delivered without delay
-	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_SYNC, 0644)
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_SYNC, 0644)
 	if err != nil {
 		return err
 	}
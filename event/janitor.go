@@ -16,21 +16,23 @@ type EventJanitor struct {
 	pattern    string
 	delaySec   uint
 	ctx        context.Context
+	cancel     context.CancelFunc
 }
 
 func NewEventJanitor(log slog.Handler, eventDir string, delaySec uint) (EventJanitor, error) {
-	pattern := fmt.Sprintf("%v/netreact-?????????????-???.json", eventDir)
+	pattern := fmt.Sprintf("%v/netreact-?????????????-???*.json", eventDir)
 	if _, err := filepath.Glob(pattern); err != nil {
 		return EventJanitor{}, err
 	}
 
-	// no cancel function here, as the only case this should shut down is when shutting down entire application
-	ctx := context.Background()
+	// cancelled when the janitor is replaced on config reload
+	ctx, cancel := context.WithCancel(context.Background())
 	return EventJanitor{
 		logHandler: log,
 		pattern:    pattern,
 		delaySec:   delaySec,
 		ctx:        ctx,
+		cancel:     cancel,
 	}, nil
 }
 
@@ -38,16 +40,25 @@ func (j EventJanitor) Start() {
 	go func() {
 		for {
 			duration := time.Duration(j.delaySec) * time.Second
-			time.Sleep(duration)
-			j.CleanupEventFiles()
+			select {
+			case <-j.ctx.Done():
+				return
+			case <-time.After(duration):
+				j.CleanupEventFiles()
+			}
 		}
 	}()
 }
 
+// Stop stops the periodic cleanup, e.g. before starting a janitor with a new config
+func (j EventJanitor) Stop() {
+	j.cancel()
+}
+
 func (j EventJanitor) CleanupEventFiles() {
 	files, _ := filepath.Glob(j.pattern)
 	for _, file := range files {
-		re := regexp.MustCompile("netreact-(?P<timestamp>[0-9]{13})-[0-9]{3}.json$")
+		re := regexp.MustCompile("netreact-(?P<timestamp>[0-9]{13})-[0-9]{3}(-[0-9]+)?.json$")
 		matches := re.FindStringSubmatch(file)
 
 		if len(matches) == 0 {
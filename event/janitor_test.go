@@ -23,6 +23,12 @@ func Test_newEventJanitor(t *testing.T) {
 	if err != nil {
 		t.Fatal("unexpected error creating a test file")
 	}
+	// same millisecond, with a counter suffix
+	matchingSuffixFileName := fmt.Sprintf("../out/netreact-%v-100-1.json", nowMillis)
+	err = os.WriteFile(matchingSuffixFileName, []byte(`{"match": true}`), 0644)
+	if err != nil {
+		t.Fatal("unexpected error creating a test file")
+	}
 
 	// should not get removed by the janitor
 	notMatchingFileName := fmt.Sprintf("../out/netreact-%v-100.json", nowPlus2Secs)
@@ -56,6 +62,10 @@ func Test_newEventJanitor(t *testing.T) {
 		_, err := os.Stat(matchingFileName)
 		return err != nil
 	}, 20, 100*time.Millisecond, "matching file not removed")
+	assertThat(t, func() bool {
+		_, err := os.Stat(matchingSuffixFileName)
+		return err != nil
+	}, 20, 100*time.Millisecond, "matching file with suffix not removed")
 
 	if _, err := os.Stat(notMatchingFileName); err != nil {
 		t.Fatal("not matching file removed")
@@ -3,14 +3,40 @@ package event
 type Notification struct {
 	// IP and MAC addresses are stored as strings due to:
 	// https://github.com/golang/go/issues/29678
-	EventType         string   `json:"eventType"`
-	Ip                string   `json:"ip"`
-	Mac               string   `json:"mac"`
-	FirstTs           int64    `json:"firstTs,omitempty"`
-	Ts                int64    `json:"ts"`
-	Count             int      `json:"count,omitempty"`
-	MacVendor         string   `json:"macVendor"`
-	ExpectedCidrRange string   `json:"expectedCidrRange"`
-	OtherIps          []string `json:"otherIps,omitempty"`
-	OtherMacs         []string `json:"otherMacs,omitempty"`
+	EventType           string        `json:"eventType"`
+	Ip                  string        `json:"ip"`
+	Mac                 string        `json:"mac"`
+	TargetIp            string        `json:"targetIp,omitempty"`
+	FirstTs             int64         `json:"firstTs,omitempty"`
+	Ts                  int64         `json:"ts"`
+	Count               int           `json:"count,omitempty"`
+	MacVendor           string        `json:"macVendor"`
+	LocallyAdministered bool          `json:"locallyAdministered,omitempty"`
+	Multicast           bool          `json:"multicast,omitempty"`
+	ExpectedCidrRange   string        `json:"expectedCidrRange"`
+	OtherIps            []string      `json:"otherIps,omitempty"`
+	OtherMacs           []string      `json:"otherMacs,omitempty"`
+	Scope               string        `json:"scope,omitempty"`
+	PacketRate          float64       `json:"packetRate,omitempty"`
+	Threshold           float64       `json:"threshold,omitempty"`
+	BaselineRate        float64       `json:"baselineRate,omitempty"`
+	WindowSec           uint          `json:"windowSec,omitempty"`
+	ScannedRange        string        `json:"scannedRange,omitempty"`
+	TargetCount         int           `json:"targetCount,omitempty"`
+	RequestCount        int           `json:"requestCount,omitempty"`
+	ClaimedIps          []string      `json:"claimedIps,omitempty"`
+	FlipCount           int           `json:"flipCount,omitempty"`
+	FlipIntervalMs      int64         `json:"flipIntervalMs,omitempty"`
+	OwnerHistory        []OwnerChange `json:"ownerHistory,omitempty"`
+	ConflictType        string        `json:"conflictType,omitempty"`
+	ConflictingMac      string        `json:"conflictingMac,omitempty"`
+	Rules               []string      `json:"rules,omitempty"`
+	ExpectedIps         []string      `json:"expectedIps,omitempty"`
+	ExpectedMacs        []string      `json:"expectedMacs,omitempty"`
+	LastSeenTs          int64         `json:"lastSeenTs,omitempty"`
+}
+
+type OwnerChange struct {
+	Mac string `json:"mac"`
+	Ts  int64  `json:"ts"`
 }
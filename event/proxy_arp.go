@@ -0,0 +1,91 @@
+package event
+
+import (
+	"net"
+	"slices"
+
+	"github.com/google/gopacket/layers"
+	"github.com/ipastusi/netreact/config"
+)
+
+type ProxyArpDetector struct {
+	windowMs int64
+	minIps   int
+	routers  map[string]struct{}
+	// MAC to the IP addresses it replied for, with the timestamp of the last reply for each
+	claims map[string]map[string]int64
+}
+
+func NewProxyArpDetector(cfg config.ProxyArpConfig) *ProxyArpDetector {
+	routers := map[string]struct{}{}
+	for _, router := range cfg.RouterMacs {
+		// normalize the format, config validation makes sure it parses
+		mac, _ := net.ParseMAC(router)
+		routers[mac.String()] = struct{}{}
+	}
+
+	return &ProxyArpDetector{
+		windowMs: int64(*cfg.WindowSec) * 1000,
+		minIps:   int(*cfg.MinIps),
+		routers:  routers,
+		claims:   map[string]map[string]int64{},
+	}
+}
+
+func (d *ProxyArpDetector) Detect(extArpEvent ExtendedArpEvent) []Alert {
+	if extArpEvent.Operation != layers.ARPReply || extArpEvent.Ip.IsUnspecified() {
+		return nil
+	}
+
+	mac, ip := extArpEvent.Mac.String(), extArpEvent.Ip.String()
+	if _, ok := d.routers[mac]; ok {
+		return nil
+	}
+
+	claimed, ok := d.claims[mac]
+	if !ok {
+		claimed = map[string]int64{}
+		d.claims[mac] = claimed
+	}
+	d.expire(claimed, extArpEvent.Ts)
+	_, ok = claimed[ip]
+	claimed[ip] = extArpEvent.Ts
+	if ok {
+		// reported only when another IP gets claimed
+		return nil
+	}
+
+	if len(claimed) < d.minIps {
+		return nil
+	}
+
+	notification := extArpEvent.toAlertNotification(ProxyArpSuspected)
+	for claimedIp := range claimed {
+		notification.ClaimedIps = append(notification.ClaimedIps, claimedIp)
+	}
+	slices.SortFunc(notification.ClaimedIps, compareIps)
+	return []Alert{{Type: ProxyArpSuspected, Notification: notification}}
+}
+
+// Check forgets the claims older than the window, so that the claimed IPs of the MACs no longer replying don't pile up
+func (d *ProxyArpDetector) Check(ts int64) []Alert {
+	for mac, claimed := range d.claims {
+		d.expire(claimed, ts)
+		if len(claimed) == 0 {
+			delete(d.claims, mac)
+		}
+	}
+	return nil
+}
+
+func (d *ProxyArpDetector) expire(claimed map[string]int64, ts int64) {
+	for ip, claimTs := range claimed {
+		if ts-claimTs > d.windowMs {
+			delete(claimed, ip)
+		}
+	}
+}
+
+func compareIps(a, b string) int {
+	return slices.Compare(net.ParseIP(a).To16(), net.ParseIP(b).To16())
+}
@@ -0,0 +1,117 @@
+package event_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+	"github.com/ipastusi/netreact/config"
+	"github.com/ipastusi/netreact/event"
+)
+
+func newArpReply(ip string, mac string, ts int64) event.ExtendedArpEvent {
+	extArpEvent := newExtArpEvent(ip, mac, ts)
+	extArpEvent.Operation = layers.ARPReply
+	return extArpEvent
+}
+
+func newProxyArpDetector(minIps uint, routerMacs ...string) *event.ProxyArpDetector {
+	enabled := true
+	windowSec := uint(60)
+	return event.NewProxyArpDetector(config.ProxyArpConfig{
+		Enabled:    &enabled,
+		WindowSec:  &windowSec,
+		MinIps:     &minIps,
+		RouterMacs: routerMacs,
+	})
+}
+
+func Test_ProxyArpDetector(t *testing.T) {
+	t.Parallel()
+
+	detector := newProxyArpDetector(3)
+	startTs := int64(1749913040000)
+	mac := "00:00:00:00:00:01"
+
+	replies := []struct {
+		ip             string
+		expectedAlerts int
+	}{
+		{"10.0.0.10", 0},
+		{"10.0.0.9", 0},
+		{"10.0.0.9", 0},
+		{"10.0.0.100", 1},
+		// already reported
+		{"10.0.0.100", 0},
+		{"10.0.0.11", 1},
+	}
+
+	var lastAlerts []event.Alert
+	for i, reply := range replies {
+		alerts := detector.Detect(newArpReply(reply.ip, mac, startTs+int64(i)))
+		if len(alerts) != reply.expectedAlerts {
+			t.Fatalf("unexpected alerts for reply %v, expected: %v, got: %v", i, reply.expectedAlerts, alerts)
+		}
+		if len(alerts) > 0 {
+			lastAlerts = alerts
+		}
+	}
+
+	notification := lastAlerts[0].Notification
+	if lastAlerts[0].Type != event.ProxyArpSuspected || notification.EventType != "PROXY_ARP_SUSPECTED" {
+		t.Fatalf("unexpected alert: %v", notification)
+	}
+	expectedIps := []string{"10.0.0.9", "10.0.0.10", "10.0.0.11", "10.0.0.100"}
+	if !slices.Equal(notification.ClaimedIps, expectedIps) {
+		t.Fatalf("unexpected claimed IPs, expected: %v, got: %v", expectedIps, notification.ClaimedIps)
+	}
+}
+
+func Test_ProxyArpDetectorIgnored(t *testing.T) {
+	t.Parallel()
+
+	detector := newProxyArpDetector(2, "00:00:00:00:00:0A")
+	startTs := int64(1749913040000)
+
+	for i := range 5 {
+		ip := fmt.Sprintf("10.0.0.%v", i+1)
+		// router
+		if alerts := detector.Detect(newArpReply(ip, "00:00:00:00:00:0a", startTs+int64(i))); len(alerts) != 0 {
+			t.Fatal("unexpected alerts for router:", alerts)
+		}
+		// requests
+		if alerts := detector.Detect(newArpRequest(ip, "00:00:00:00:00:01", "10.0.0.254", startTs+int64(i))); len(alerts) != 0 {
+			t.Fatal("unexpected alerts for requests:", alerts)
+		}
+	}
+}
+
+func Test_ProxyArpDetectorExpiredClaims(t *testing.T) {
+	t.Parallel()
+
+	detector := newProxyArpDetector(3)
+	startTs := int64(1749913040000)
+	mac := "00:00:00:00:00:01"
+
+	detector.Detect(newArpReply("10.0.0.1", mac, startTs))
+	detector.Detect(newArpReply("10.0.0.2", mac, startTs+30_000))
+	// the first claim is out of the window
+	if alerts := detector.Detect(newArpReply("10.0.0.3", mac, startTs+61_000)); len(alerts) != 0 {
+		t.Fatal("unexpected alerts for expired claim:", alerts)
+	}
+	// the second one too, once checked
+	detector.Check(startTs + 91_000)
+	if alerts := detector.Detect(newArpReply("10.0.0.4", mac, startTs+92_000)); len(alerts) != 0 {
+		t.Fatal("unexpected alerts after check:", alerts)
+	}
+
+	alerts := detector.Detect(newArpReply("10.0.0.5", mac, startTs+93_000))
+	if len(alerts) != 1 {
+		t.Fatal("expected alert, got:", alerts)
+	}
+	expectedIps := []string{"10.0.0.3", "10.0.0.4", "10.0.0.5"}
+	if !slices.Equal(alerts[0].Notification.ClaimedIps, expectedIps) {
+		t.Fatalf("unexpected claimed IPs, expected: %v, got: %v", expectedIps, alerts[0].Notification.ClaimedIps)
+	}
+}
@@ -0,0 +1,124 @@
+package event
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/google/gopacket/layers"
+	"github.com/ipastusi/netreact/config"
+)
+
+type scanTracker struct {
+	// target IP to the timestamp of the last request for it
+	targets      map[uint32]int64
+	requests     *rateCounter
+	lastTs       int64
+	lastPruneSec int64
+	scanning     bool
+}
+
+type ArpScanDetector struct {
+	windowSec    uint
+	minTargets   int
+	senders      map[string]*scanTracker
+	lastSweepSec int64
+}
+
+func NewArpScanDetector(cfg config.ArpScanConfig) *ArpScanDetector {
+	return &ArpScanDetector{
+		windowSec:  *cfg.WindowSec,
+		minTargets: int(*cfg.MinTargets),
+		senders:    map[string]*scanTracker{},
+	}
+}
+
+func (d *ArpScanDetector) Detect(extArpEvent ExtendedArpEvent) []Alert {
+	ts := extArpEvent.Ts
+	d.sweep(ts)
+
+	target := extArpEvent.TargetIp.To4()
+	if extArpEvent.Operation != layers.ARPRequest || target == nil || target.IsUnspecified() || target.Equal(extArpEvent.Ip) {
+		// not a request, or a gratuitous ARP announcement
+		return nil
+	}
+
+	mac := extArpEvent.Mac.String()
+	tracker, ok := d.senders[mac]
+	if !ok {
+		tracker = &scanTracker{
+			targets:  map[uint32]int64{},
+			requests: newRateCounter(d.windowSec),
+		}
+		d.senders[mac] = tracker
+	}
+	tracker.targets[binary.BigEndian.Uint32(target)] = ts
+	tracker.requests.add(ts)
+	tracker.lastTs = ts
+	d.prune(tracker, ts)
+
+	if len(tracker.targets) < d.minTargets {
+		tracker.scanning = false
+		return nil
+	} else if tracker.scanning {
+		// already reported
+		return nil
+	}
+
+	tracker.scanning = true
+	notification := extArpEvent.toAlertNotification(ArpScanDetected)
+	notification.ScannedRange = tracker.scannedRange()
+	notification.TargetCount = len(tracker.targets)
+	notification.RequestCount = tracker.requests.count(ts)
+	notification.WindowSec = d.windowSec
+	return []Alert{{Type: ArpScanDetected, Notification: notification}}
+}
+
+// prune forgets the targets requested before the window, at most once per second for each sender
+func (d *ArpScanDetector) prune(tracker *scanTracker, ts int64) {
+	sec := ts / 1000
+	if sec == tracker.lastPruneSec {
+		return
+	}
+	tracker.lastPruneSec = sec
+
+	windowMs := int64(d.windowSec) * 1000
+	for target, targetTs := range tracker.targets {
+		if ts-targetTs > windowMs {
+			delete(tracker.targets, target)
+		}
+	}
+}
+
+// sweep forgets the senders which have been quiet for the entire window
+func (d *ArpScanDetector) sweep(ts int64) {
+	sec := ts / 1000
+	if sec-d.lastSweepSec < int64(d.windowSec) {
+		return
+	}
+	d.lastSweepSec = sec
+
+	windowMs := int64(d.windowSec) * 1000
+	for mac, tracker := range d.senders {
+		if ts-tracker.lastTs > windowMs {
+			delete(d.senders, mac)
+		}
+	}
+}
+
+func (t *scanTracker) scannedRange() string {
+	var first, last uint32
+	for target := range t.targets {
+		if first == 0 || target < first {
+			first = target
+		}
+		if target > last {
+			last = target
+		}
+	}
+	return fmt.Sprintf("%v-%v", uint32ToIp(first), uint32ToIp(last))
+}
+
+func uint32ToIp(ip uint32) net.IP {
+	return binary.BigEndian.AppendUint32(nil, ip)
+}
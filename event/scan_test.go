@@ -0,0 +1,100 @@
+package event_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+	"github.com/ipastusi/netreact/config"
+	"github.com/ipastusi/netreact/event"
+)
+
+func newArpRequest(ip string, mac string, targetIp string, ts int64) event.ExtendedArpEvent {
+	extArpEvent := newExtArpEvent(ip, mac, ts)
+	extArpEvent.TargetIp = net.ParseIP(targetIp)
+	extArpEvent.Operation = layers.ARPRequest
+	return extArpEvent
+}
+
+func newScanDetector(windowSec uint, minTargets uint) *event.ArpScanDetector {
+	enabled := true
+	return event.NewArpScanDetector(config.ArpScanConfig{
+		Enabled:    &enabled,
+		WindowSec:  &windowSec,
+		MinTargets: &minTargets,
+	})
+}
+
+func Test_ArpScanDetector(t *testing.T) {
+	t.Parallel()
+
+	detector := newScanDetector(10, 5)
+	startTs := int64(1749913040000)
+	scanner := "00:00:00:00:00:01"
+
+	var alerts []event.Alert
+	for i := 1; i <= 10; i++ {
+		targetIp := fmt.Sprintf("10.0.0.%v", 10+i)
+		alerts = append(alerts, detector.Detect(newArpRequest("10.0.0.1", scanner, targetIp, startTs+int64(i*100)))...)
+		if i == 4 && len(alerts) != 0 {
+			t.Fatal("unexpected alerts below min targets:", alerts)
+		}
+	}
+
+	if len(alerts) != 1 {
+		t.Fatal("expected a single alert, got:", alerts)
+	}
+	notification := alerts[0].Notification
+	if alerts[0].Type != event.ArpScanDetected || notification.EventType != "ARP_SCAN_DETECTED" {
+		t.Fatalf("unexpected alert: %v", notification)
+	}
+	if notification.ScannedRange != "10.0.0.11-10.0.0.15" || notification.TargetCount != 5 || notification.RequestCount != 5 {
+		t.Fatalf("unexpected alert details: %v", notification)
+	}
+}
+
+func Test_ArpScanDetectorIgnored(t *testing.T) {
+	t.Parallel()
+
+	detector := newScanDetector(10, 2)
+	startTs := int64(1749913040000)
+	sender := "00:00:00:00:00:01"
+
+	reply := newArpRequest("10.0.0.1", sender, "10.0.0.2", startTs+2)
+	reply.Operation = layers.ARPReply
+
+	data := map[string]event.ExtendedArpEvent{
+		"announcement": newArpRequest("10.0.0.1", sender, "10.0.0.1", startTs),
+		"probe target": newArpRequest("10.0.0.1", sender, "0.0.0.0", startTs+1),
+		"reply":        reply,
+	}
+
+	for name, e := range data {
+		if alerts := detector.Detect(e); len(alerts) != 0 {
+			t.Fatalf("unexpected alerts for %v: %v", name, alerts)
+		}
+	}
+
+	// only a single real target so far
+	if alerts := detector.Detect(newArpRequest("10.0.0.1", sender, "10.0.0.3", startTs+3)); len(alerts) != 0 {
+		t.Fatal("unexpected alerts:", alerts)
+	}
+}
+
+func Test_ArpScanDetectorWindow(t *testing.T) {
+	t.Parallel()
+
+	detector := newScanDetector(2, 3)
+	startTs := int64(1749913040000)
+	sender := "00:00:00:00:00:01"
+
+	// slow enough to stay below min targets
+	for i := 1; i <= 10; i++ {
+		targetIp := fmt.Sprintf("10.0.0.%v", 10+i)
+		alerts := detector.Detect(newArpRequest("10.0.0.1", sender, targetIp, startTs+int64(i*1500)))
+		if len(alerts) != 0 {
+			t.Fatalf("unexpected alerts for request %v: %v", i, alerts)
+		}
+	}
+}
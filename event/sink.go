@@ -0,0 +1,6 @@
+package event
+
+// Sink receives every notification stored as an event file, e.g. to keep the history of events in a database
+type Sink interface {
+	StoreEvent(notification Notification) error
+}
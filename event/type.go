@@ -1,5 +1,7 @@
 package event
 
+import "fmt"
+
 type Type int
 
 const (
@@ -10,6 +12,7 @@ const (
 	NewUnexpectedIpPacket     Type = 104
 	NewIpForMacPacket         Type = 105
 	NewMacForIpPacket         Type = 106
+	NewRandomizedMacPacket    Type = 107
 	NewHost                   Type = 200
 	NewLinkLocalUnicastHost   Type = 201
 	NewUnspecifiedHost        Type = 202
@@ -17,8 +20,28 @@ const (
 	NewUnexpectedIpHost       Type = 204
 	NewIpForMacHost           Type = 205
 	NewMacForIpHost           Type = 206
+	NewRandomizedMacHost      Type = 207
+	ArpFloodHost              Type = 300
+	ArpFloodGlobal            Type = 301
+	ArpScanDetected           Type = 302
+	ProxyArpSuspected         Type = 303
+	IpFlipFlop                Type = 304
+	IpConflict                Type = 305
+	VendorDenied              Type = 306
+	VendorNotAllowed          Type = 307
+	NewVendor                 Type = 308
+	BindingViolation          Type = 309
+	BindingMissing            Type = 310
 )
 
+// TypeFromCode returns the event type with the numeric code, e.g. 309 for BindingViolation
+func TypeFromCode(code uint) (Type, error) {
+	if eventType := Type(code); eventType.describe() != "UNKNOWN" {
+		return eventType, nil
+	}
+	return 0, fmt.Errorf("unknown event type %v", code)
+}
+
 func (e Type) describe() string {
 	switch e {
 	case NewPacket:
@@ -35,6 +58,8 @@ func (e Type) describe() string {
 		return "NEW_IP_FOR_MAC_PACKET"
 	case NewMacForIpPacket:
 		return "NEW_MAC_FOR_IP_PACKET"
+	case NewRandomizedMacPacket:
+		return "NEW_RANDOMIZED_MAC_PACKET"
 	case NewHost:
 		return "NEW_HOST"
 	case NewLinkLocalUnicastHost:
@@ -49,6 +74,28 @@ func (e Type) describe() string {
 		return "NEW_IP_FOR_MAC_HOST"
 	case NewMacForIpHost:
 		return "NEW_MAC_FOR_IP_HOST"
+	case NewRandomizedMacHost:
+		return "NEW_RANDOMIZED_MAC_HOST"
+	case ArpFloodHost, ArpFloodGlobal:
+		return "ARP_FLOOD"
+	case ArpScanDetected:
+		return "ARP_SCAN_DETECTED"
+	case ProxyArpSuspected:
+		return "PROXY_ARP_SUSPECTED"
+	case IpFlipFlop:
+		return "IP_FLIP_FLOP"
+	case IpConflict:
+		return "IP_CONFLICT"
+	case VendorDenied:
+		return "VENDOR_DENIED"
+	case VendorNotAllowed:
+		return "VENDOR_NOT_ALLOWED"
+	case NewVendor:
+		return "NEW_VENDOR"
+	case BindingViolation:
+		return "BINDING_VIOLATION"
+	case BindingMissing:
+		return "BINDING_MISSING"
 	default:
 		return "UNKNOWN"
 	}
@@ -0,0 +1,37 @@
+package event
+
+import (
+	"maps"
+
+	"github.com/ipastusi/netreact/oui"
+)
+
+// UnseenVendorDetector reports the first host seen with a MAC vendor not seen before on the network. Hosts with an unknown
+// MAC vendor are ignored.
+type UnseenVendorDetector struct {
+	knownVendors map[string]struct{}
+}
+
+func NewUnseenVendorDetector(knownVendors map[string]struct{}) *UnseenVendorDetector {
+	vendors := maps.Clone(knownVendors)
+	if vendors == nil {
+		vendors = map[string]struct{}{}
+	}
+	return &UnseenVendorDetector{
+		knownVendors: vendors,
+	}
+}
+
+func (d *UnseenVendorDetector) Detect(extArpEvent ExtendedArpEvent) []Alert {
+	vendor := extArpEvent.MacVendor
+	if vendor == "" || vendor == oui.UnknownVendor {
+		return nil
+	}
+	if _, ok := d.knownVendors[vendor]; ok {
+		return nil
+	}
+
+	d.knownVendors[vendor] = struct{}{}
+	notification := extArpEvent.toAlertNotification(NewVendor)
+	return []Alert{{Type: NewVendor, Notification: notification}}
+}
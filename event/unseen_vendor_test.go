@@ -0,0 +1,48 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/ipastusi/netreact/event"
+)
+
+func Test_UnseenVendorDetector(t *testing.T) {
+	t.Parallel()
+
+	knownVendors := map[string]struct{}{"Apple, Inc.": {}}
+	detector := event.NewUnseenVendorDetector(knownVendors)
+
+	data := []struct {
+		ip            string
+		mac           string
+		vendor        string
+		expectedAlert bool
+	}{
+		{"10.0.0.1", "00:00:00:00:00:01", "Apple, Inc.", false},
+		{"10.0.0.2", "00:00:00:00:00:02", "Hewlett Packard", true},
+		{"10.0.0.3", "00:00:00:00:00:03", "Hewlett Packard", false},
+		{"10.0.0.4", "da:a1:19:00:00:04", "Unknown", false},
+		{"10.0.0.5", "00:00:00:00:00:05", "Dell Inc.", true},
+	}
+
+	for i, d := range data {
+		extArpEvent := newExtArpEvent(d.ip, d.mac, 1749913040000+int64(i))
+		extArpEvent.MacVendor = d.vendor
+		extArpEvent.Count = 1
+
+		alerts := detector.Detect(extArpEvent)
+		if (len(alerts) == 1) != d.expectedAlert || len(alerts) > 1 {
+			t.Fatalf("unexpected alerts for %v, expected alert: %v, got: %v", d.vendor, d.expectedAlert, alerts)
+		}
+		if d.expectedAlert {
+			notification := alerts[0].Notification
+			if alerts[0].Type != event.NewVendor || notification.Ip != d.ip || notification.Mac != d.mac || notification.MacVendor != d.vendor {
+				t.Fatalf("unexpected alert for %v: %v", d.vendor, alerts[0])
+			}
+		}
+	}
+
+	if _, ok := knownVendors["Hewlett Packard"]; ok {
+		t.Fatal("known vendors passed to the detector should not be modified")
+	}
+}
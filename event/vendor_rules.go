@@ -0,0 +1,107 @@
+package event
+
+import (
+	"bytes"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ipastusi/netreact/config"
+	"github.com/ipastusi/netreact/oui"
+)
+
+type vendorRule struct {
+	name        string
+	allow       bool
+	cidrRange   *net.IPNet
+	vendors     []string
+	patterns    []*regexp.Regexp
+	ouiPrefixes [][]byte
+}
+
+// VendorRuleDetector checks the MAC vendor of every new host against the allow and deny rules. A host is reported if
+// it matches any deny rule covering its IP, or if it doesn't match any of the allow rules covering its IP.
+type VendorRuleDetector struct {
+	rules []vendorRule
+}
+
+func NewVendorRuleDetector(rules []config.VendorRuleConfig) (*VendorRuleDetector, error) {
+	detector := &VendorRuleDetector{}
+	for _, ruleConfig := range rules {
+		_, cidrRange, err := net.ParseCIDR(*ruleConfig.CidrRange)
+		if err != nil {
+			return nil, err
+		}
+
+		rule := vendorRule{
+			name:      *ruleConfig.Name,
+			allow:     *ruleConfig.Action == "allow",
+			cidrRange: cidrRange,
+			vendors:   ruleConfig.Vendors,
+		}
+		for _, pattern := range ruleConfig.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			rule.patterns = append(rule.patterns, re)
+		}
+		for _, prefix := range ruleConfig.OuiPrefixes {
+			ouiPrefix, err := oui.ParsePrefix(prefix)
+			if err != nil {
+				return nil, err
+			}
+			rule.ouiPrefixes = append(rule.ouiPrefixes, ouiPrefix)
+		}
+		detector.rules = append(detector.rules, rule)
+	}
+	return detector, nil
+}
+
+func (d *VendorRuleDetector) Detect(extArpEvent ExtendedArpEvent) []Alert {
+	if extArpEvent.Count != 1 || len(d.rules) == 0 {
+		return nil
+	}
+
+	var denied, allowedBy, notAllowedBy []string
+	for _, rule := range d.rules {
+		if !rule.cidrRange.Contains(extArpEvent.Ip) {
+			continue
+		}
+
+		matches := rule.matches(extArpEvent.Mac, extArpEvent.MacVendor)
+		if rule.allow && matches {
+			allowedBy = append(allowedBy, rule.name)
+		} else if rule.allow {
+			notAllowedBy = append(notAllowedBy, rule.name)
+		} else if matches {
+			denied = append(denied, rule.name)
+		}
+	}
+
+	var alerts []Alert
+	if len(denied) > 0 {
+		alerts = append(alerts, toVendorRuleAlert(extArpEvent, VendorDenied, denied))
+	}
+	if len(allowedBy) == 0 && len(notAllowedBy) > 0 {
+		alerts = append(alerts, toVendorRuleAlert(extArpEvent, VendorNotAllowed, notAllowedBy))
+	}
+	return alerts
+}
+
+func (r vendorRule) matches(mac net.HardwareAddr, vendor string) bool {
+	return slices.ContainsFunc(r.vendors, func(v string) bool {
+		return strings.EqualFold(v, vendor)
+	}) || slices.ContainsFunc(r.patterns, func(re *regexp.Regexp) bool {
+		return re.MatchString(vendor)
+	}) || slices.ContainsFunc(r.ouiPrefixes, func(prefix []byte) bool {
+		return bytes.HasPrefix(mac, prefix)
+	})
+}
+
+func toVendorRuleAlert(extArpEvent ExtendedArpEvent, eventType Type, rules []string) Alert {
+	notification := extArpEvent.toAlertNotification(eventType)
+	notification.Rules = rules
+	return Alert{Type: eventType, Notification: notification}
+}
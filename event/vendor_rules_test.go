@@ -0,0 +1,74 @@
+package event_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ipastusi/netreact/config"
+	"github.com/ipastusi/netreact/event"
+)
+
+func newVendorRule(name string, action string, cidrRange string, vendors []string, patterns []string, ouiPrefixes []string) config.VendorRuleConfig {
+	return config.VendorRuleConfig{
+		Name:        &name,
+		Action:      &action,
+		CidrRange:   &cidrRange,
+		Vendors:     vendors,
+		Patterns:    patterns,
+		OuiPrefixes: ouiPrefixes,
+	}
+}
+
+func Test_VendorRuleDetector(t *testing.T) {
+	t.Parallel()
+
+	rules := []config.VendorRuleConfig{
+		newVendorRule("cameras", "deny", "0.0.0.0/0", []string{"hangzhou hikvision digital technology co.,ltd."}, nil, nil),
+		newVendorRule("printers", "deny", "0.0.0.0/0", nil, []string{"^Hewlett"}, nil),
+		newVendorRule("servers", "allow", "10.0.1.0/24", []string{"Dell Inc."}, nil, []string{"2c:cf:67"}),
+		newVendorRule("servers hp", "allow", "10.0.1.0/25", nil, []string{"^Hewlett"}, nil),
+	}
+	detector, err := event.NewVendorRuleDetector(rules)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	data := map[string]struct {
+		ip            string
+		mac           string
+		vendor        string
+		count         int
+		expectedTypes []event.Type
+		expectedRules [][]string
+	}{
+		"denied by name":          {"10.0.0.1", "00:00:00:00:00:01", "Hangzhou Hikvision Digital Technology Co.,Ltd.", 1, []event.Type{event.VendorDenied}, [][]string{{"cameras"}}},
+		"denied by pattern":       {"10.0.0.2", "00:00:00:00:00:02", "Hewlett Packard", 1, []event.Type{event.VendorDenied}, [][]string{{"printers"}}},
+		"not a new host":          {"10.0.0.2", "00:00:00:00:00:02", "Hewlett Packard", 2, nil, nil},
+		"allowed by name":         {"10.0.1.200", "00:00:00:00:00:03", "Dell Inc.", 1, nil, nil},
+		"allowed by OUI prefix":   {"10.0.1.200", "2c:cf:67:00:00:04", "Raspberry Pi (Trading) Ltd", 1, nil, nil},
+		"not allowed":             {"10.0.1.200", "00:00:00:00:00:05", "Apple, Inc.", 1, []event.Type{event.VendorNotAllowed}, [][]string{{"servers"}}},
+		"not allowed by any":      {"10.0.1.1", "00:00:00:00:00:06", "Apple, Inc.", 1, []event.Type{event.VendorNotAllowed}, [][]string{{"servers", "servers hp"}}},
+		"allowed by another rule": {"10.0.1.1", "00:00:00:00:00:07", "Dell Inc.", 1, nil, nil},
+		"denied and not allowed":  {"10.0.1.200", "00:00:00:00:00:08", "Hewlett Packard", 1, []event.Type{event.VendorDenied, event.VendorNotAllowed}, [][]string{{"printers"}, {"servers"}}},
+		"outside allow rules":     {"10.0.2.1", "00:00:00:00:00:09", "Apple, Inc.", 1, nil, nil},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			extArpEvent := newExtArpEvent(d.ip, d.mac, 1749913040000)
+			extArpEvent.MacVendor = d.vendor
+			extArpEvent.Count = d.count
+
+			alerts := detector.Detect(extArpEvent)
+			if len(alerts) != len(d.expectedTypes) {
+				t.Fatalf("unexpected alerts, expected: %v, got: %v", d.expectedTypes, alerts)
+			}
+			for i, alert := range alerts {
+				if alert.Type != d.expectedTypes[i] || !slices.Equal(alert.Notification.Rules, d.expectedRules[i]) {
+					t.Fatalf("unexpected alert, expected: %v %v, got: %v %v", d.expectedTypes[i], d.expectedRules[i], alert.Type, alert.Notification.Rules)
+				}
+			}
+		})
+	}
+}
@@ -8,7 +8,9 @@ require (
 	github.com/google/go-cmp v0.7.0
 	github.com/google/gopacket v1.1.19
 	github.com/kaptinlin/jsonschema v0.4.15
+	github.com/klauspost/compress v1.18.0
 	github.com/rivo/tview v0.42.0
+	go.etcd.io/bbolt v1.4.3
 	golang.org/x/sys v0.35.0
 )
 
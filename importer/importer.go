@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ipastusi/netreact/state"
+)
+
+// ReadArpwatch reads an arpwatch database, e.g. arp.dat. Each line holds a MAC address, an IP address, the unix
+// timestamp of the last sighting and, optionally, the hostname and the interface, separated by tabs. Hostnames are
+// imported as notes.
+func ReadArpwatch(reader io.Reader) ([]state.Item, error) {
+	var items []state.Item
+	scanner := bufio.NewScanner(reader)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("invalid arpwatch entry at line %v: %v", lineNo, line)
+		}
+
+		mac, err := parseMac(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAC address at line %v: %v", lineNo, fields[0])
+		}
+		ip := net.ParseIP(fields[1])
+		if ip == nil || ip.To4() == nil {
+			return nil, fmt.Errorf("invalid IP address at line %v: %v", lineNo, fields[1])
+		}
+		ts, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil || ts < 0 {
+			return nil, fmt.Errorf("invalid timestamp at line %v: %v", lineNo, fields[2])
+		}
+
+		item := state.Item{
+			Ip:      ip.String(),
+			Mac:     mac,
+			FirstTs: ts * 1000,
+			LastTs:  ts * 1000,
+			Count:   1,
+		}
+		if len(fields) > 3 {
+			item.Notes = fields[3]
+		}
+		if len(fields) > 4 {
+			item.Interface = fields[4]
+		}
+		items = append(items, item)
+	}
+	return items, scanner.Err()
+}
+
+// ReadArpScan reads the output of arp-scan, where each response is reported as a line with an IP address, a MAC address
+// and the MAC vendor, separated by tabs. All the other lines, e.g. the header and the summary, are skipped. As arp-scan
+// doesn't report any timestamps, all the hosts are imported as seen at ts.
+func ReadArpScan(reader io.Reader, ts int64) ([]state.Item, error) {
+	var items []state.Item
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		mac, err := parseMac(fields[1])
+		if ip == nil || ip.To4() == nil || err != nil {
+			continue
+		}
+		items = append(items, state.Item{
+			Ip:      ip.String(),
+			Mac:     mac,
+			FirstTs: ts,
+			LastTs:  ts,
+			Count:   1,
+		})
+	}
+	return items, scanner.Err()
+}
+
+// parseMac normalizes the MAC address, also accepting the octets without leading zeros written by arpwatch, e.g.
+// 0:1a:2b:3:4:5
+func parseMac(mac string) (string, error) {
+	octets := strings.Split(mac, ":")
+	if len(octets) == 6 {
+		for i, octet := range octets {
+			if len(octet) == 1 {
+				octets[i] = "0" + octet
+			}
+		}
+	}
+	hwAddr, err := net.ParseMAC(strings.Join(octets, ":"))
+	if err != nil || len(hwAddr) != 6 {
+		return "", fmt.Errorf("invalid MAC address: %v", mac)
+	}
+	return hwAddr.String(), nil
+}
+
+// Import adds the imported hosts to the state, deduplicating them by their IP-MAC pair. Hosts already in the state only
+// get their first and last seen timestamps extended, so importing the same data twice doesn't change anything. Returns
+// the number of added hosts.
+func Import(appState state.AppState, items []state.Item) (state.AppState, int) {
+	index := map[string]int{}
+	for i, item := range appState.Items {
+		index[item.Ip+","+item.Mac] = i
+	}
+
+	added := 0
+	for _, item := range items {
+		key := item.Ip + "," + item.Mac
+		if i, ok := index[key]; ok {
+			existing := &appState.Items[i]
+			existing.FirstTs = min(existing.FirstTs, item.FirstTs)
+			existing.LastTs = max(existing.LastTs, item.LastTs)
+			if existing.Notes == "" {
+				existing.Notes = item.Notes
+			}
+			continue
+		}
+		index[key] = len(appState.Items)
+		appState.Items = append(appState.Items, item)
+		added++
+	}
+	return appState, added
+}
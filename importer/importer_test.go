@@ -0,0 +1,109 @@
+package importer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ipastusi/netreact/importer"
+	"github.com/ipastusi/netreact/state"
+)
+
+func Test_ReadArpwatch(t *testing.T) {
+	t.Parallel()
+
+	arpDat := "0:1a:2b:3:4:5\t192.168.1.10\t1751972610\tprinter.lan\teth0\n" +
+		"b4:b6:86:01:02:03\t192.168.1.11\t1751972620\n" +
+		"\n"
+	items, err := importer.ReadArpwatch(strings.NewReader(arpDat))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := []state.Item{
+		{Ip: "192.168.1.10", Mac: "00:1a:2b:03:04:05", FirstTs: 1751972610000, LastTs: 1751972610000, Count: 1, Notes: "printer.lan", Interface: "eth0"},
+		{Ip: "192.168.1.11", Mac: "b4:b6:86:01:02:03", FirstTs: 1751972620000, LastTs: 1751972620000, Count: 1},
+	}
+	if diff := cmp.Diff(expected, items); diff != "" {
+		t.Fatal("unexpected items:", diff)
+	}
+}
+
+func Test_ReadArpwatchInvalid(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]string{
+		"missing fields": "00:1a:2b:03:04:05\t192.168.1.10\n",
+		"invalid MAC":    "00:1a:2b:03:04\t192.168.1.10\t1751972610\n",
+		"invalid IP":     "00:1a:2b:03:04:05\t192.168.1.300\t1751972610\n",
+		"IPv6":           "00:1a:2b:03:04:05\tfe80::1\t1751972610\n",
+		"invalid ts":     "00:1a:2b:03:04:05\t192.168.1.10\tyesterday\n",
+	}
+
+	for name, arpDat := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := importer.ReadArpwatch(strings.NewReader(arpDat)); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func Test_ReadArpScan(t *testing.T) {
+	t.Parallel()
+
+	output := "Interface: eth0, type: EN10MB, MAC: 2c:cf:67:0c:6c:a4, IPv4: 192.168.1.2\n" +
+		"Starting arp-scan 1.10.0 with 256 hosts (https://github.com/royhills/arp-scan)\n" +
+		"192.168.1.1\t00:1a:2b:03:04:05\tXEROX CORPORATION\n" +
+		"192.168.1.11\tb4:b6:86:01:02:03\tHewlett Packard\n" +
+		"192.168.1.11\tb4:b6:86:01:02:03\tHewlett Packard (DUP: 2)\n" +
+		"\n" +
+		"3 packets received by filter, 0 packets dropped by kernel\n" +
+		"Ending arp-scan 1.10.0: 256 hosts scanned in 1.942 seconds (131.82 hosts/sec). 2 responded\n"
+	items, err := importer.ReadArpScan(strings.NewReader(output), 1751972610000)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := []state.Item{
+		{Ip: "192.168.1.1", Mac: "00:1a:2b:03:04:05", FirstTs: 1751972610000, LastTs: 1751972610000, Count: 1},
+		{Ip: "192.168.1.11", Mac: "b4:b6:86:01:02:03", FirstTs: 1751972610000, LastTs: 1751972610000, Count: 1},
+		{Ip: "192.168.1.11", Mac: "b4:b6:86:01:02:03", FirstTs: 1751972610000, LastTs: 1751972610000, Count: 1},
+	}
+	if diff := cmp.Diff(expected, items); diff != "" {
+		t.Fatal("unexpected items:", diff)
+	}
+}
+
+func Test_Import(t *testing.T) {
+	t.Parallel()
+
+	appState := state.NewAppState()
+	appState.Items = []state.Item{
+		{Ip: "192.168.1.1", Mac: "00:1a:2b:03:04:05", FirstTs: 1751972610000, LastTs: 1751972650000, Count: 10},
+	}
+	items := []state.Item{
+		{Ip: "192.168.1.1", Mac: "00:1a:2b:03:04:05", FirstTs: 1751972600000, LastTs: 1751972600000, Count: 1, Notes: "router"},
+		{Ip: "192.168.1.11", Mac: "b4:b6:86:01:02:03", FirstTs: 1751972610000, LastTs: 1751972610000, Count: 1},
+		{Ip: "192.168.1.11", Mac: "b4:b6:86:01:02:03", FirstTs: 1751972620000, LastTs: 1751972620000, Count: 1},
+	}
+
+	appState, added := importer.Import(appState, items)
+	expected := []state.Item{
+		{Ip: "192.168.1.1", Mac: "00:1a:2b:03:04:05", FirstTs: 1751972600000, LastTs: 1751972650000, Count: 10, Notes: "router"},
+		{Ip: "192.168.1.11", Mac: "b4:b6:86:01:02:03", FirstTs: 1751972610000, LastTs: 1751972620000, Count: 1},
+	}
+	if added != 1 {
+		t.Fatal("unexpected number of added hosts:", added)
+	}
+	if diff := cmp.Diff(expected, appState.Items); diff != "" {
+		t.Fatal("unexpected items:", diff)
+	}
+
+	// importing again doesn't change anything
+	appState, added = importer.Import(appState, items)
+	if diff := cmp.Diff(expected, appState.Items); added != 0 || diff != "" {
+		t.Fatalf("unexpected second import, added: %v, diff: %v", added, diff)
+	}
+}
@@ -0,0 +1,148 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ipastusi/netreact/state"
+	"github.com/klauspost/compress/zstd"
+)
+
+// packet log record, as written by the ARP event handler
+type logRecord struct {
+	Time time.Time `json:"time"`
+	Msg  string    `json:"msg"`
+	Ip   string    `json:"IP"`
+	Mac  string    `json:"MAC"`
+}
+
+const packetLogMsg = "ARP packet received"
+
+// ErrNoPackets is returned for a log without any packet records, e.g. one written with logging.packets disabled
+var ErrNoPackets = errors.New("no packet records in the log, they're only logged with logging.packets enabled")
+
+// ReadLog rebuilds the hosts from the packet log, i.e. the lines logged for every ARP packet in the json or text format,
+// optionally compressed with gzip or zstd, e.g. once rotated. Only the packets logged between fromTs and toTs are
+// counted, 0 meaning no limit. Lines other than the packet records, or not parsable at all, are skipped, but a non-empty
+// log without any packet records is reported as ErrNoPackets.
+func ReadLog(reader io.Reader, fromTs int64, toTs int64) ([]state.Item, error) {
+	reader, closeReader, err := decompress(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader()
+
+	var items []state.Item
+	index := map[string]int{}
+	var lines, packets int
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines++
+		var record logRecord
+		if err = json.Unmarshal([]byte(line), &record); err != nil {
+			record, err = parseTextRecord(line)
+		}
+		if err != nil || record.Msg != packetLogMsg {
+			continue
+		}
+		packets++
+		ip := net.ParseIP(record.Ip)
+		mac, err := net.ParseMAC(record.Mac)
+		if ip == nil || err != nil {
+			continue
+		}
+		ts := record.Time.UnixMilli()
+		if (fromTs > 0 && ts < fromTs) || (toTs > 0 && ts > toTs) {
+			continue
+		}
+
+		key := ip.String() + "," + mac.String()
+		if i, ok := index[key]; ok {
+			items[i].FirstTs = min(items[i].FirstTs, ts)
+			items[i].LastTs = max(items[i].LastTs, ts)
+			items[i].Count++
+			continue
+		}
+		index[key] = len(items)
+		items = append(items, state.Item{Ip: ip.String(), Mac: mac.String(), FirstTs: ts, LastTs: ts, Count: 1})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	} else if lines > 0 && packets == 0 {
+		return nil, ErrNoPackets
+	}
+	return items, nil
+}
+
+// parseTextRecord parses a line logged in the text format, i.e. key=value pairs separated by spaces, with the values
+// containing spaces or quotes quoted
+func parseTextRecord(line string) (logRecord, error) {
+	var record logRecord
+	for line != "" {
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok || key == "" || strings.Contains(key, " ") {
+			return logRecord{}, errors.New("not a text log record")
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return logRecord{}, err
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = strings.TrimPrefix(rest[len(quoted):], " ")
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+		}
+
+		switch key {
+		case "time":
+			ts, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return logRecord{}, err
+			}
+			record.Time = ts
+		case "msg":
+			record.Msg = value
+		case "IP":
+			record.Ip = value
+		case "MAC":
+			record.Mac = value
+		}
+		line = rest
+	}
+	return record, nil
+}
+
+// decompress detects gzip and zstd compression by the magic bytes
+func decompress(reader io.Reader) (io.Reader, func(), error) {
+	bufReader := bufio.NewReader(reader)
+	magic, _ := bufReader.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gzipReader, err := gzip.NewReader(bufReader)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gzipReader, func() { _ = gzipReader.Close() }, nil
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zstdReader, err := zstd.NewReader(bufReader)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zstdReader, zstdReader.Close, nil
+	}
+	return bufReader, func() {}, nil
+}
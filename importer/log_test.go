@@ -0,0 +1,124 @@
+package importer_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ipastusi/netreact/importer"
+	"github.com/ipastusi/netreact/state"
+	"github.com/klauspost/compress/zstd"
+)
+
+const packetLog = `{"time":"2025-07-08T10:00:00Z","level":"INFO","msg":"ARP packet received","IP":"192.168.1.10","MAC":"00:00:00:01:02:03"}
+{"time":"2025-07-08T10:00:05Z","level":"INFO","msg":"ARP packet received","IP":"192.168.1.11","MAC":"b4:b6:86:01:02:03"}
+{"time":"2025-07-08T10:00:10Z","level":"INFO","msg":"something else","IP":"192.168.1.12","MAC":"b4:b6:86:01:02:04"}
+not json at all
+{"time":"2025-07-08T10:00:15Z","level":"INFO","msg":"ARP packet received","IP":"invalid","MAC":"b4:b6:86:01:02:05"}
+{"time":"2025-07-09T10:00:00Z","level":"INFO","msg":"ARP packet received","IP":"192.168.1.10","MAC":"00:00:00:01:02:03"}
+`
+
+func compress(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "gzip":
+		writer := gzip.NewWriter(&buf)
+		if _, err = writer.Write([]byte(packetLog)); err == nil {
+			err = writer.Close()
+		}
+	case "zstd":
+		var writer *zstd.Encoder
+		if writer, err = zstd.NewWriter(&buf); err == nil {
+			if _, err = writer.Write([]byte(packetLog)); err == nil {
+				err = writer.Close()
+			}
+		}
+	default:
+		buf.WriteString(packetLog)
+	}
+	if err != nil {
+		t.Fatal("unexpected error compressing:", err)
+	}
+	return buf.Bytes()
+}
+
+func Test_ReadLog(t *testing.T) {
+	t.Parallel()
+
+	expected := []state.Item{
+		{Ip: "192.168.1.10", Mac: "00:00:00:01:02:03", FirstTs: 1751968800000, LastTs: 1752055200000, Count: 2},
+		{Ip: "192.168.1.11", Mac: "b4:b6:86:01:02:03", FirstTs: 1751968805000, LastTs: 1751968805000, Count: 1},
+	}
+	for _, format := range []string{"plain", "gzip", "zstd"} {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+			items, err := importer.ReadLog(bytes.NewReader(compress(t, format)), 0, 0)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if diff := cmp.Diff(expected, items); diff != "" {
+				t.Fatal("unexpected items:", diff)
+			}
+		})
+	}
+}
+
+func Test_ReadLogText(t *testing.T) {
+	t.Parallel()
+
+	textLog := `time=2025-07-08T12:00:00.000+02:00 level=INFO msg="ARP packet received" IP=192.168.1.10 MAC=00:00:00:01:02:03 Vendor="XEROX CORPORATION"
+time=2025-07-08T10:00:10.000Z level=INFO msg="Event generated" IP=192.168.1.12 MAC=b4:b6:86:01:02:04
+time=2025-07-09T10:00:00.000Z level=INFO msg="ARP packet received" IP=192.168.1.10 MAC=00:00:00:01:02:03 Note="say \"hi\""
+`
+	items, err := importer.ReadLog(strings.NewReader(textLog), 0, 0)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	expected := []state.Item{
+		{Ip: "192.168.1.10", Mac: "00:00:00:01:02:03", FirstTs: 1751968800000, LastTs: 1752055200000, Count: 2},
+	}
+	if diff := cmp.Diff(expected, items); diff != "" {
+		t.Fatal("unexpected items:", diff)
+	}
+}
+
+func Test_ReadLogNoPackets(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]struct {
+		log         string
+		expectedErr error
+	}{
+		"empty":      {"", nil},
+		"no packets": {`{"time":"2025-07-08T10:00:10Z","level":"INFO","msg":"Event generated","IP":"192.168.1.12"}` + "\n", importer.ErrNoPackets},
+	}
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			items, err := importer.ReadLog(strings.NewReader(d.log), 0, 0)
+			if !errors.Is(err, d.expectedErr) || len(items) != 0 {
+				t.Fatalf("unexpected result, items: %v, error: %v", items, err)
+			}
+		})
+	}
+}
+
+func Test_ReadLogTimeRange(t *testing.T) {
+	t.Parallel()
+
+	items, err := importer.ReadLog(strings.NewReader(packetLog), 1751968801000, 1752000000000)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	expected := []state.Item{
+		{Ip: "192.168.1.11", Mac: "b4:b6:86:01:02:03", FirstTs: 1751968805000, LastTs: 1751968805000, Count: 1},
+	}
+	if diff := cmp.Diff(expected, items); diff != "" {
+		t.Fatal("unexpected items:", diff)
+	}
+}
@@ -0,0 +1,206 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/ipastusi/netreact/event"
+)
+
+// record layout: payload length (4 bytes), CRC32 of the payload (4 bytes) and the payload itself
+const (
+	headerSize     = 8
+	maxPayloadSize = 1024
+)
+
+// Journal is a write-ahead log of the ARP events applied to the host cache, so that the sightings since the last saved
+// state can be replayed after a crash. Each record has a sequence number, and the saved state keeps the sequence number
+// of the last record it includes, so records already in the state are skipped when replaying.
+type Journal struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  uint64
+	// first write error since the last Sync, so that Append doesn't need to be checked on every packet
+	err error
+}
+
+func Open(fileName string) (*Journal, error) {
+	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{file: file}, nil
+}
+
+// Replay calls apply for every record newer than the sequence number of the saved state, in order. A torn or corrupted
+// record at the end, e.g. written just before a crash, is cut off, along with everything after it. Returns the number of
+// replayed records.
+func (j *Journal) Replay(stateSeq uint64, apply func(arpEvent event.ArpEvent)) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	j.seq = stateSeq
+	reader := bufio.NewReader(j.file)
+	var validSize int64
+	var replayed int
+	for {
+		payload, err := readRecord(reader)
+		if err != nil {
+			break
+		}
+		seq, arpEvent, err := decode(payload)
+		if err != nil {
+			break
+		}
+		validSize += int64(headerSize + len(payload))
+		if seq > stateSeq {
+			apply(arpEvent)
+			replayed++
+		}
+		j.seq = max(j.seq, seq)
+	}
+
+	if err := j.file.Truncate(validSize); err != nil {
+		return replayed, err
+	}
+	_, err := j.file.Seek(validSize, io.SeekStart)
+	return replayed, err
+}
+
+// Append writes the record straight to the file, so it survives a crash of the process, and to the disk on next Sync
+func (j *Journal) Append(arpEvent event.ArpEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.seq++
+	payload := encode(j.seq, arpEvent)
+	record := make([]byte, headerSize, headerSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	record = append(record, payload...)
+	if _, err := j.file.Write(record); err != nil && j.err == nil {
+		j.err = err
+	}
+}
+
+// Seq returns the sequence number of the last appended record
+func (j *Journal) Seq() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.seq
+}
+
+// Sync flushes the journal to the disk, returning the first error since the last Sync, if any
+func (j *Journal) Sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	err := j.err
+	j.err = nil
+	if syncErr := j.file.Sync(); err == nil {
+		err = syncErr
+	}
+	return err
+}
+
+// Compact empties the journal once the state including all the records up to seq is saved. Records appended since are
+// kept, and skipped on replay if already included in a later state.
+func (j *Journal) Compact(seq uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if seq != j.seq {
+		return nil
+	}
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := j.file.Seek(0, io.SeekStart)
+	return err
+}
+
+func (j *Journal) Close() error {
+	return errors.Join(j.Sync(), j.file.Close())
+}
+
+func readRecord(reader *bufio.Reader) ([]byte, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size > maxPayloadSize {
+		return nil, errors.New("invalid journal record size")
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errors.New("invalid journal record checksum")
+	}
+	return payload, nil
+}
+
+// payload layout: sequence number, timestamp, operation, VLAN, followed by the length-prefixed IP, MAC, target IP and
+// interface name
+func encode(seq uint64, arpEvent event.ArpEvent) []byte {
+	payload := binary.BigEndian.AppendUint64(nil, seq)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(arpEvent.Ts))
+	payload = binary.BigEndian.AppendUint16(payload, arpEvent.Operation)
+	payload = binary.BigEndian.AppendUint16(payload, arpEvent.Vlan)
+	for _, field := range [][]byte{compactIp(arpEvent.Ip), arpEvent.Mac, compactIp(arpEvent.TargetIp), []byte(arpEvent.Interface)} {
+		// interface names are at most 15 bytes on Linux, IPs and MACs even shorter
+		field = field[:min(len(field), 255)]
+		payload = append(payload, byte(len(field)))
+		payload = append(payload, field...)
+	}
+	return payload
+}
+
+func decode(payload []byte) (uint64, event.ArpEvent, error) {
+	errInvalid := errors.New("invalid journal record")
+	if len(payload) < 20 {
+		return 0, event.ArpEvent{}, errInvalid
+	}
+	seq := binary.BigEndian.Uint64(payload[0:8])
+	arpEvent := event.ArpEvent{
+		Ts:        int64(binary.BigEndian.Uint64(payload[8:16])),
+		Operation: binary.BigEndian.Uint16(payload[16:18]),
+		Vlan:      binary.BigEndian.Uint16(payload[18:20]),
+	}
+
+	var fields [4][]byte
+	rest := payload[20:]
+	for i := range fields {
+		if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+			return 0, event.ArpEvent{}, errInvalid
+		}
+		fields[i] = rest[1 : 1+int(rest[0])]
+		rest = rest[1+int(rest[0]):]
+	}
+	arpEvent.Ip = net.IP(fields[0])
+	arpEvent.Mac = net.HardwareAddr(fields[1])
+	if len(fields[2]) > 0 {
+		arpEvent.TargetIp = net.IP(fields[2])
+	}
+	arpEvent.Interface = string(fields[3])
+	return seq, arpEvent, nil
+}
+
+// compactIp stores IPv4 addresses in 4 bytes rather than 16
+func compactIp(ip net.IP) []byte {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
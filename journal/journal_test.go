@@ -0,0 +1,157 @@
+package journal_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ipastusi/netreact/event"
+	"github.com/ipastusi/netreact/journal"
+)
+
+func getArpEvents() []event.ArpEvent {
+	mac1, _ := net.ParseMAC("00:00:00:01:02:03")
+	mac2, _ := net.ParseMAC("b4:b6:86:01:02:03")
+	return []event.ArpEvent{
+		{Ip: net.ParseIP("10.0.0.1").To4(), Mac: mac1, Ts: 1749913040850, TargetIp: net.ParseIP("10.0.0.254").To4(), Operation: 1, Interface: "eth0"},
+		{Ip: net.ParseIP("10.0.0.2").To4(), Mac: mac2, Ts: 1749913040851, Operation: 2, Interface: "eth0", Vlan: 10},
+		{Ip: net.ParseIP("10.0.0.1").To4(), Mac: mac1, Ts: 1749913040852, TargetIp: net.ParseIP("10.0.0.2").To4(), Operation: 1, Interface: "eth1"},
+	}
+}
+
+func replay(t *testing.T, j *journal.Journal, stateSeq uint64) []event.ArpEvent {
+	t.Helper()
+	var replayed []event.ArpEvent
+	count, err := j.Replay(stateSeq, func(arpEvent event.ArpEvent) {
+		replayed = append(replayed, arpEvent)
+	})
+	if err != nil {
+		t.Fatal("unexpected error replaying journal:", err)
+	}
+	if count != len(replayed) {
+		t.Fatalf("unexpected number of replayed records, expected: %v, got: %v", len(replayed), count)
+	}
+	return replayed
+}
+
+func Test_AppendReplay(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "nrstate.json.journal")
+	j, err := journal.Open(fileName)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	arpEvents := getArpEvents()
+	for _, arpEvent := range arpEvents {
+		j.Append(arpEvent)
+	}
+	if err = j.Close(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	j, err = journal.Open(fileName)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	defer func() { _ = j.Close() }()
+
+	// the first record is already included in the state
+	replayed := replay(t, j, 1)
+	if diff := cmp.Diff(arpEvents[1:], replayed); diff != "" {
+		t.Fatal("unexpected replayed events:", diff)
+	}
+	if seq := j.Seq(); seq != 3 {
+		t.Fatal("unexpected sequence number:", seq)
+	}
+
+	// appended after the replayed records
+	j.Append(arpEvents[0])
+	replayed = replay(t, j, 3)
+	if diff := cmp.Diff(arpEvents[:1], replayed); diff != "" {
+		t.Fatal("unexpected replayed events:", diff)
+	}
+}
+
+func Test_ReplayTornRecord(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "nrstate.json.journal")
+	j, err := journal.Open(fileName)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	arpEvents := getArpEvents()
+	for _, arpEvent := range arpEvents {
+		j.Append(arpEvent)
+	}
+	if err = j.Close(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// crash in the middle of writing the last record
+	info, err := os.Stat(fileName)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if err = os.Truncate(fileName, info.Size()-3); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	j, err = journal.Open(fileName)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	defer func() { _ = j.Close() }()
+	replayed := replay(t, j, 0)
+	if diff := cmp.Diff(arpEvents[:2], replayed); diff != "" {
+		t.Fatal("unexpected replayed events:", diff)
+	}
+
+	// the torn record is cut off, so new records are readable
+	j.Append(arpEvents[2])
+	replayed = replay(t, j, 0)
+	if diff := cmp.Diff(arpEvents, replayed); diff != "" {
+		t.Fatal("unexpected replayed events:", diff)
+	}
+}
+
+func Test_Compact(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "nrstate.json.journal")
+	j, err := journal.Open(fileName)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	defer func() { _ = j.Close() }()
+
+	arpEvents := getArpEvents()
+	j.Append(arpEvents[0])
+	j.Append(arpEvents[1])
+
+	// records appended after the state was saved are kept
+	savedSeq := j.Seq() - 1
+	if err = j.Compact(savedSeq); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if replayed := replay(t, j, savedSeq); len(replayed) != 1 {
+		t.Fatal("unexpected replayed events:", replayed)
+	}
+
+	if err = j.Compact(j.Seq()); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	info, err := os.Stat(fileName)
+	if err != nil || info.Size() != 0 {
+		t.Fatalf("unexpected journal after compaction, info: %v, error: %v", info, err)
+	}
+
+	// sequence numbers keep growing after compaction
+	j.Append(arpEvents[2])
+	if seq := j.Seq(); seq != 3 {
+		t.Fatal("unexpected sequence number:", seq)
+	}
+}
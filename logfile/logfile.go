@@ -0,0 +1,207 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Policy defines when the log file is rotated and how many rotated files are kept
+type Policy struct {
+	// rotate once the file would grow beyond MaxSize bytes, 0 to disable
+	MaxSize int64
+	// rotate once the file has been written to for MaxAge, also across restarts and reopens, 0 to disable
+	MaxAge time.Duration
+	// keep at most MaxBackups rotated files, named <name>.1 (the most recent) to <name>.<MaxBackups>
+	MaxBackups int
+	// gzip the rotated files, adding a .gz suffix
+	Compress bool
+}
+
+func (p Policy) enabled() bool {
+	return p.MaxSize > 0 || p.MaxAge > 0
+}
+
+// File is a log file opened in append mode, rotated according to the policy, and reopened on demand, e.g. after it was
+// moved away by logrotate
+type File struct {
+	mu     sync.Mutex
+	name   string
+	policy Policy
+	file   *os.File
+	size   int64
+	// when writing to the file started, which MaxAge counts from
+	startedAt time.Time
+	// rotated files are compressed in the background, so writes aren't blocked for long
+	compressing sync.WaitGroup
+	// error compressing the last rotated file, only read after waiting for the compression
+	compressErr error
+}
+
+func Open(name string, policy Policy) (*File, error) {
+	f := &File{name: name, policy: policy}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file, f.size, f.startedAt = file, info.Size(), f.startTime(info)
+	return nil
+}
+
+// startTime tells when writing to the opened file started, so that restarts and reopens don't reset its age. Reopening the
+// current file keeps its age, and an existing file is as old as the last rotation, i.e. the last write to the most recent
+// rotated file, or as its own last write if it was never rotated.
+func (f *File) startTime(info os.FileInfo) time.Time {
+	if f.file != nil {
+		if current, err := f.file.Stat(); err == nil && os.SameFile(current, info) {
+			return f.startedAt
+		}
+	}
+	if info.Size() == 0 {
+		return time.Now()
+	}
+	for _, suffix := range []string{"", ".gz"} {
+		if rotated, err := os.Stat(backupName(f.name, 1, suffix)); err == nil {
+			return rotated.ModTime()
+		}
+	}
+	return info.ModTime()
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.policy.enabled() && f.size > 0 && f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *File) shouldRotate(size int64) bool {
+	return (f.policy.MaxSize > 0 && f.size+size > f.policy.MaxSize) ||
+		(f.policy.MaxAge > 0 && time.Since(f.startedAt) >= f.policy.MaxAge)
+}
+
+// Reopen closes and reopens the log file by name, so that writes go to a new file once the current one was moved away,
+// e.g. by logrotate, which sends SIGHUP afterward. Writes keep going to the current file if reopening fails.
+func (f *File) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+	return current.Close()
+}
+
+// Rotate rotates the log file regardless of the policy
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rotate()
+}
+
+// rotate moves the current file to <name>.1, shifting the older ones and removing those beyond the retention count
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	// the previously rotated file must be compressed before being shifted
+	f.compressing.Wait()
+	errs := []error{f.compressErr}
+	f.compressErr = nil
+
+	for i := f.policy.MaxBackups; i >= 1; i-- {
+		for _, suffix := range []string{"", ".gz"} {
+			from := backupName(f.name, i, suffix)
+			var err error
+			if i == f.policy.MaxBackups {
+				err = os.Remove(from)
+			} else {
+				err = os.Rename(from, backupName(f.name, i+1, suffix))
+			}
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if f.policy.MaxBackups == 0 {
+		errs = append(errs, os.Remove(f.name))
+	} else {
+		rotated := backupName(f.name, 1, "")
+		if err := os.Rename(f.name, rotated); err != nil {
+			errs = append(errs, err)
+		} else if f.policy.Compress {
+			f.compressing.Add(1)
+			go func() {
+				defer f.compressing.Done()
+				f.compressErr = compress(rotated)
+			}()
+		}
+	}
+
+	// a new file is opened even if shifting the rotated files failed, so logging carries on
+	errs = append(errs, f.open())
+	return errors.Join(errs...)
+}
+
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.compressing.Wait()
+	return errors.Join(f.compressErr, f.file.Close())
+}
+
+func backupName(name string, i int, suffix string) string {
+	return fmt.Sprintf("%v.%v%v", name, i, suffix)
+}
+
+// compress gzips the file into <fileName>.gz and removes the original
+func compress(fileName string) error {
+	src, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	tmpName := fileName + ".gz.tmp"
+	dst, err := os.OpenFile(tmpName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(dst)
+	_, err = io.Copy(writer, src)
+	err = errors.Join(err, writer.Close(), dst.Close())
+	if err == nil {
+		err = os.Rename(tmpName, fileName+".gz")
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	return os.Remove(fileName)
+}
@@ -0,0 +1,180 @@
+package logfile_test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipastusi/netreact/logfile"
+)
+
+func readFile(t *testing.T, fileName string) string {
+	t.Helper()
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	defer func() { _ = file.Close() }()
+	var reader io.Reader = file
+	if strings.HasSuffix(fileName, ".gz") {
+		if reader, err = gzip.NewReader(file); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	return string(data)
+}
+
+func write(t *testing.T, f *logfile.File, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+}
+
+func Test_RotateBySize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "netreact.log")
+	f, err := logfile.Open(fileName, logfile.Policy{MaxSize: 10, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	write(t, f, "line 1\n", "line 2\n", "line 3\n", "line 4\n")
+	if err = f.Close(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// the oldest line is beyond the retention count
+	expected := map[string]string{
+		"netreact.log":      "line 4\n",
+		"netreact.log.1.gz": "line 3\n",
+		"netreact.log.2.gz": "line 2\n",
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if len(entries) != len(expected) {
+		t.Fatal("unexpected files:", entries)
+	}
+	for name, content := range expected {
+		if got := readFile(t, filepath.Join(dir, name)); got != content {
+			t.Fatalf("unexpected content of %v, expected: %q, got: %q", name, content, got)
+		}
+	}
+}
+
+func Test_RotateByAge(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "netreact.log")
+	f, err := logfile.Open(fileName, logfile.Policy{MaxAge: 50 * time.Millisecond, MaxBackups: 1})
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	write(t, f, "line 1\n", "line 2\n")
+	time.Sleep(100 * time.Millisecond)
+	write(t, f, "line 3\n")
+
+	if got := readFile(t, fileName+".1"); got != "line 1\nline 2\n" {
+		t.Fatalf("unexpected rotated content: %q", got)
+	}
+	if got := readFile(t, fileName); got != "line 3\n" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+}
+
+func Test_RotateByAgeAcrossReopens(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "netreact.log")
+	f, err := logfile.Open(fileName, logfile.Policy{MaxAge: 50 * time.Millisecond, MaxBackups: 1})
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// reopening the same file, e.g. on SIGHUP without logrotate, doesn't reset its age
+	write(t, f, "line 1\n")
+	time.Sleep(100 * time.Millisecond)
+	if err = f.Reopen(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	write(t, f, "line 2\n")
+
+	if got := readFile(t, fileName+".1"); got != "line 1\n" {
+		t.Fatalf("unexpected rotated content: %q", got)
+	}
+}
+
+func Test_RotateByAgeAfterRestart(t *testing.T) {
+	t.Parallel()
+
+	// the current file was started with the last rotation, two hours ago, and written to since
+	fileName := filepath.Join(t.TempDir(), "netreact.log")
+	for name, content := range map[string]string{fileName + ".1": "line 1\n", fileName: "line 2\n"} {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+	rotatedAt := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(fileName+".1", rotatedAt, rotatedAt); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	f, err := logfile.Open(fileName, logfile.Policy{MaxAge: time.Hour, MaxBackups: 2})
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	defer func() { _ = f.Close() }()
+	write(t, f, "line 3\n")
+
+	if got := readFile(t, fileName+".1"); got != "line 2\n" {
+		t.Fatalf("unexpected rotated content: %q", got)
+	}
+	if got := readFile(t, fileName); got != "line 3\n" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+}
+
+func Test_Reopen(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "netreact.log")
+	f, err := logfile.Open(fileName, logfile.Policy{})
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// moved away by logrotate, still written to until reopened
+	write(t, f, "line 1\n")
+	if err = os.Rename(fileName, fileName+".1"); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	write(t, f, "line 2\n")
+	if err = f.Reopen(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	write(t, f, "line 3\n")
+
+	if got := readFile(t, fileName+".1"); got != "line 1\nline 2\n" {
+		t.Fatalf("unexpected rotated content: %q", got)
+	}
+	if got := readFile(t, fileName); got != "line 3\n" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+}
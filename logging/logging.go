@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// NewHandler returns a handler writing the records at the level or above to all the writers, as JSON or text
+func NewHandler(writers []io.Writer, format string, level string) (slog.Handler, error) {
+	var slogLevel slog.Level
+	if err := slogLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %v", level)
+	}
+	options := &slog.HandlerOptions{Level: slogLevel}
+
+	var handlers []slog.Handler
+	for _, writer := range writers {
+		switch format {
+		case "json":
+			handlers = append(handlers, slog.NewJSONHandler(writer, options))
+		case "text":
+			handlers = append(handlers, slog.NewTextHandler(writer, options))
+		default:
+			return nil, fmt.Errorf("invalid log format %v", format)
+		}
+	}
+	return fanOutHandler(handlers), nil
+}
+
+// fanOutHandler passes the records to all the handlers enabled for their level. Unlike slog.Logger, the packet and error
+// records are passed to Handle directly, so the level is checked here as well.
+type fanOutHandler []slog.Handler
+
+func (h fanOutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanOutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanOutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanOutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h fanOutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanOutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
@@ -0,0 +1,49 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipastusi/netreact/logging"
+)
+
+func Test_NewHandler(t *testing.T) {
+	t.Parallel()
+
+	var first, second bytes.Buffer
+	handler, err := logging.NewHandler([]io.Writer{&first, &second}, "text", "warn")
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// records are passed to Handle directly, without checking the level first
+	for _, level := range []slog.Level{slog.LevelInfo, slog.LevelError} {
+		record := slog.NewRecord(time.Now(), level, "message at "+level.String(), 0)
+		if err = handler.Handle(context.Background(), record); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	for _, buf := range []*bytes.Buffer{&first, &second} {
+		output := buf.String()
+		if strings.Contains(output, "message at INFO") || !strings.Contains(output, `level=ERROR msg="message at ERROR"`) {
+			t.Fatal("unexpected output:", output)
+		}
+	}
+}
+
+func Test_NewHandlerInvalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := logging.NewHandler([]io.Writer{io.Discard}, "xml", "info"); err == nil {
+		t.Fatal("expected error on invalid format")
+	}
+	if _, err := logging.NewHandler([]io.Writer{io.Discard}, "json", "verbose"); err == nil {
+		t.Fatal("expected error on invalid level")
+	}
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"os"
 	"os/signal"
+	"reflect"
 	"slices"
 	"syscall"
 	"time"
@@ -20,21 +23,24 @@ import (
 	"github.com/ipastusi/netreact/cli"
 	"github.com/ipastusi/netreact/config"
 	"github.com/ipastusi/netreact/event"
+	"github.com/ipastusi/netreact/journal"
+	"github.com/ipastusi/netreact/logfile"
+	"github.com/ipastusi/netreact/logging"
 	"github.com/ipastusi/netreact/state"
+	"github.com/ipastusi/netreact/store"
 )
 
 func main() {
-	flags := cli.GetFlags()
-	var cfgData []byte
-	var err error
-	if flags.ConfigFileName != nil && *flags.ConfigFileName != "" {
-		cfgData, err = os.ReadFile(*flags.ConfigFileName)
-		if err != nil {
-			exitOnError(err)
-		}
+	if len(os.Args) > 1 && os.Args[1] == "state" {
+		exitOnError(cli.RunState(os.Args[2:], os.Stdout))
+		os.Exit(0)
+	} else if len(os.Args) > 1 && os.Args[1] == "rebuild-state" {
+		exitOnError(cli.RunRebuildState(os.Args[2:], os.Stdout))
+		os.Exit(0)
 	}
 
-	cfg, err := config.GetConfig(cfgData, flags.IfaceName, flags.LogFileName, flags.PromiscMode, flags.StateFileName)
+	flags := cli.GetFlags()
+	cfg, err := loadConfig(flags)
 	if *flags.RenderConfig == true {
 		renderedConfig, errMarshal := yaml.Marshal(cfg)
 		fmt.Printf("%v", string(renderedConfig))
@@ -59,8 +65,7 @@ func main() {
 	iface, err := net.InterfaceByName(ifaceName)
 	exitOnError(err)
 
-	logFileName := *cfg.LogFileName
-	logFile, err := os.OpenFile(logFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	logFile, logHandler, err := openLog(cfg)
 	exitOnError(err)
 
 	maxSize := int32(64)
@@ -71,20 +76,66 @@ func main() {
 	err = pcapHandle.SetBPFFilter(*cfg.BpfFilter)
 	exitOnError(err)
 
+	encryptionConfig := *cfg.StateConfig.EncryptionConfig
+	stateKey, err := state.ReadKey(encryptionConfig.KeyFile, encryptionConfig.KeyEnv)
+	exitOnError(err)
 	hostCache := cache.NewHostCache()
+	var journalSeq uint64
 	if cfg.StateFileName != nil {
 		var stateBytes []byte
 		stateBytes, err = os.ReadFile(*cfg.StateFileName)
 		if err != nil && !errors.Is(err, os.ErrNotExist) {
 			exitOnError(err)
 		} else if err == nil {
-			errs := state.ValidateState(stateBytes)
-			exitOnErrors(errs)
-			appState, err := state.FromJson(stateBytes)
+			stateBytes, err = state.Decode(stateBytes, stateKey)
+			exitOnError(err)
+			appState, err := state.Load(stateBytes)
+			if errors.Is(err, state.ErrNewerVersion) {
+				exitOnError(fmt.Errorf("%w, upgrade netreact or remove the state file", err))
+			}
 			exitOnError(err)
 			hostCache = cache.FromAppState(appState)
+			journalSeq = appState.JournalSeq
+		}
+	}
+
+	var db *store.Store
+	if cfg.DatabaseConfig.File != nil {
+		databaseConfig := *cfg.DatabaseConfig
+		db, err = store.Open(*databaseConfig.File, *databaseConfig.SightingIntervalSec, *databaseConfig.RetentionDays)
+		exitOnError(err)
+		var imported int
+		hostCache, imported, err = loadFromDatabase(db, hostCache)
+		exitOnError(err)
+		if imported > 0 {
+			logInfo(logHandler, "Hosts imported from the state file into the database", slog.Int("Hosts", imported))
 		}
 	}
+	hostCache.CollapseRandomizedMacs = *cfg.RandomizedMacConfig.Collapse
+	hostCache.OfflineAfterSec = *cfg.HostsConfig.OfflineAfterSec
+	presenceConfig := *cfg.HostsConfig.PresenceConfig
+	hostCache.Presence = cache.PresencePolicy{
+		GapSec:        *presenceConfig.GapSec,
+		RetentionDays: *presenceConfig.RetentionDays,
+		MaxIntervals:  int(*presenceConfig.MaxIntervals),
+	}
+	hostCache.MaxAgeDays = *cfg.HostsConfig.MaxAgeDays
+
+	var stateFile *StateFile
+	if cfg.StateFileName != nil {
+		stateFile = &StateFile{name: *cfg.StateFileName, backup: *cfg.StateConfig.Backup, key: stateKey}
+	}
+	if *cfg.StateConfig.Journal {
+		stateFile.journal, err = journal.Open(*cfg.StateFileName + ".journal")
+		exitOnError(err)
+		// replayed sightings only update the hosts, no events are generated for them again
+		_, err = stateFile.journal.Replay(journalSeq, func(arpEvent event.ArpEvent) {
+			hostCache.Update(arpEvent)
+		})
+		exitOnError(err)
+	}
+	_, err = pruneHosts(time.Now().UnixMilli(), hostCache, cfg.HostsConfig.ArchiveFile, stateKey, db)
+	exitOnError(err)
 
 	var uiApp *UIApp = nil
 	if *cfg.Ui {
@@ -92,74 +143,108 @@ func main() {
 		go loadUI(uiApp, ifaceName, cfg.StateFileName)
 	}
 
-	if cfg.StateFileName != nil {
-		sig := make(chan os.Signal, 1)
+	// the final save runs on the main loop, as the hosts and the journal are only ever touched from there
+	var sig chan os.Signal
+	if cfg.StateFileName != nil || db != nil {
+		sig = make(chan os.Signal, 1)
 		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-		go handleSignals(sig, hostCache, *cfg.StateFileName)
 	}
 
-	closeFile := func(file *os.File) {
-		closeErr := file.Close()
-		if closeErr != nil {
-			fmt.Println(closeErr)
-		}
-	}
+	p, err := newPipeline(cfg, logHandler, hostCache, db, nil)
+	exitOnError(err)
+	p.start()
 
-	var excludeIPs, excludeMACs, excludePairs map[string]struct{}
-	if cfg.EventsConfig.ExcludeConfig.IpFile != nil {
-		ipFlagFile, err := os.Open(*cfg.EventsConfig.ExcludeConfig.IpFile)
-		exitOnError(err)
-		excludeIPs, err = event.ReadIPs(ipFlagFile)
-		exitOnError(err)
-		closeFile(ipFlagFile)
+	localMac := []byte(iface.HardwareAddr)
+	packetSource := gopacket.NewPacketSource(pcapHandle, pcapHandle.LinkType())
+	packets := packetSource.Packets()
+	// periodic checks run on the same goroutine as packet processing, so detectors don't need any locking
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	// receiving from a nil channel blocks forever, so autosave is simply never triggered if disabled
+	var autosave <-chan time.Time
+	if cfg.StateFileName != nil && *cfg.StateConfig.AutosaveSec > 0 {
+		autosaveTicker := time.NewTicker(time.Duration(*cfg.StateConfig.AutosaveSec) * time.Second)
+		defer autosaveTicker.Stop()
+		autosave = autosaveTicker.C
 	}
-	if cfg.EventsConfig.ExcludeConfig.MacFile != nil {
-		macFlagFile, err := os.Open(*cfg.EventsConfig.ExcludeConfig.MacFile)
-		exitOnError(err)
-		excludeMACs, err = event.ReadMACs(macFlagFile)
-		exitOnError(err)
-		closeFile(macFlagFile)
+	var prune <-chan time.Time
+	if hostCache.MaxAgeDays > 0 {
+		pruneTicker := time.NewTicker(time.Minute)
+		defer pruneTicker.Stop()
+		prune = pruneTicker.C
 	}
-	if cfg.EventsConfig.ExcludeConfig.IpMacFile != nil {
-		pairsFlagFile, err := os.Open(*cfg.EventsConfig.ExcludeConfig.IpMacFile)
-		exitOnError(err)
-		excludePairs, err = event.ReadPairs(pairsFlagFile)
-		exitOnError(err)
-		closeFile(pairsFlagFile)
+	// logrotate moves or copies the log file away, and sends SIGHUP to make netreact write to a new one. The config is
+	// reloaded on SIGHUP too, or once any of the watched files changes
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	var watch <-chan time.Time
+	watcher := newConfigWatcher(flags, cfg)
+	if *cfg.ReloadConfig.WatchIntervalSec > 0 {
+		watchTicker := time.NewTicker(time.Duration(*cfg.ReloadConfig.WatchIntervalSec) * time.Second)
+		defer watchTicker.Stop()
+		watch = watchTicker.C
 	}
-
-	logHandler := slog.NewJSONHandler(logFile, nil)
-	eventDir := *cfg.EventsConfig.Directory
-	autoCleanupDelay := *cfg.EventsConfig.AutoCleanupDelaySec
-	if autoCleanupDelay > 0 {
-		janitor, err := event.NewEventJanitor(logHandler, eventDir, autoCleanupDelay)
-		exitOnError(err)
-		janitor.Start()
+	reload := func() {
+		reloaded, reloadedPipeline, ignored, err := reloadConfig(flags, cfg, p, logHandler, hostCache, db)
+		if err != nil {
+			logError(logHandler, err)
+			return
+		}
+		p.stop()
+		cfg, p = reloaded, reloadedPipeline
+		p.start()
+		hostCache.CollapseRandomizedMacs = *cfg.RandomizedMacConfig.Collapse
+		watcher.watch(flags, cfg)
+		logReload(logHandler, ignored)
 	}
-
-	filter := event.NewArpEventFilter(excludeIPs, excludeMACs, excludePairs)
-	packetEventConfig := *cfg.EventsConfig.PacketEventConfig
-	hostEventConfig := *cfg.EventsConfig.HostEventConfig
-	expectedCidrRange := *cfg.EventsConfig.ExpectedCidrRange
-	ipToMac, macToIp := hostCache.IpAndMacMaps()
-	eventHandler := event.NewArpEventHandler(logHandler, eventDir, packetEventConfig, hostEventConfig, expectedCidrRange, ipToMac, macToIp)
-	localMac := []byte(iface.HardwareAddr)
-	packetSource := gopacket.NewPacketSource(pcapHandle, pcapHandle.LinkType())
-	for packet := range packetSource.Packets() {
-		arpLayer := packet.Layer(layers.LayerTypeARP)
-		if arpLayer == nil {
-			// if you are using a custom BPF filter and this is not an ARP packet
-			continue
-		}
-
-		arp := arpLayer.(*layers.ARP)
-		if !slices.Equal(arp.SourceHwAddress, localMac) {
-			arpEvent := event.ArpEvent{
-				Ip:  net.IP(arp.SourceProtAddress),
-				Mac: net.HardwareAddr(arp.SourceHwAddress),
-				Ts:  time.Now().UnixMilli(),
+	for {
+		select {
+		case packet, ok := <-packets:
+			if !ok {
+				return
 			}
-			processArpEvent(arpEvent, hostCache, filter, eventHandler, uiApp)
+			processPacket(packet, ifaceName, localMac, hostCache, p.filter, p.handler, uiApp, db, stateFile.getJournal())
+		case now := <-ticker.C:
+			hostCache.MarkOffline(now.UnixMilli())
+			p.handler.Tick(now.UnixMilli())
+			if db != nil {
+				if err = db.Flush(); err != nil {
+					logError(logHandler, err)
+				}
+			}
+			if j := stateFile.getJournal(); j != nil {
+				if err = j.Sync(); err != nil {
+					logError(logHandler, err)
+				}
+			}
+		case <-autosave:
+			if err = stateFile.save(hostCache); err != nil {
+				logError(logHandler, err)
+			}
+		case now := <-prune:
+			pruned, err := pruneHosts(now.UnixMilli(), hostCache, cfg.HostsConfig.ArchiveFile, stateKey, db)
+			if err != nil {
+				logError(logHandler, err)
+			}
+			for _, item := range pruned {
+				p.handler.Forget(item.Ip, item.Mac)
+				if uiApp != nil {
+					uiApp.removeAndRefreshTable(item.Ip, item.Mac)
+				}
+			}
+		case <-hup:
+			if logFile != nil {
+				if err = logFile.Reopen(); err != nil {
+					logError(logHandler, err)
+				}
+			}
+			reload()
+		case <-watch:
+			if watcher.changed() {
+				reload()
+			}
+		case <-sig:
+			shutdown(hostCache, stateFile, db)
 		}
 	}
 }
@@ -178,25 +263,280 @@ func exitOnErrors(errs []error) {
 	}
 }
 
-func handleSignals(sig chan os.Signal, hostCache cache.HostCache, stateFileName string) {
-	<-sig
+// shutdown saves the state, closes the journal and the database, and exits
+func shutdown(hostCache cache.HostCache, stateFile *StateFile, db *store.Store) {
+	var errs []error
+	if stateFile != nil {
+		if err := stateFile.save(hostCache); err != nil {
+			errs = append(errs, err)
+		}
+		if stateFile.journal != nil {
+			if err := stateFile.journal.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if db != nil {
+		if err := db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	exitOnErrors(errs)
+	os.Exit(0)
+}
+
+// loadFromDatabase loads the hosts from the database, importing the hosts loaded from the state file which are missing
+// from it, e.g. all of them into a new database. For the hosts found in both, the database copy is kept, as it's updated
+// more often than the state file. Returns the number of imported hosts.
+func loadFromDatabase(db *store.Store, hostCache cache.HostCache) (cache.HostCache, int, error) {
+	appState, err := db.LoadAppState()
+	if err != nil {
+		return hostCache, 0, err
+	}
+	stored := map[string]struct{}{}
+	for _, item := range appState.Items {
+		stored[item.Ip+","+item.Mac] = struct{}{}
+	}
+
+	fileState := hostCache.ToAppState()
+	missing := state.NewAppState()
+	missing.Vendors = fileState.Vendors
+	for _, item := range fileState.Items {
+		if _, ok := stored[item.Ip+","+item.Mac]; !ok {
+			missing.Items = append(missing.Items, item)
+		}
+	}
+	if err = db.Import(missing); err != nil {
+		return hostCache, 0, err
+	}
+	appState.Items = append(appState.Items, missing.Items...)
+	appState.Vendors = append(appState.Vendors, missing.Vendors...)
+	return cache.FromAppState(appState), len(missing.Items), nil
+}
+
+// pruneHosts removes the hosts not seen for too long from the cache and the database, archiving them first if an archive
+// file is configured, encrypted with the state key
+func pruneHosts(ts int64, hostCache cache.HostCache, archiveFileName *string, key []byte, db *store.Store) ([]state.Item, error) {
+	var archive func(items []state.Item) error
+	if archiveFileName != nil {
+		archive = func(items []state.Item) error {
+			return state.Archive(*archiveFileName, items, key)
+		}
+	}
+	pruned, err := hostCache.Prune(ts, archive)
+	if db != nil {
+		for _, item := range pruned {
+			db.RemoveHost(item.Ip, item.Mac)
+		}
+	}
+	return pruned, err
+}
+
+// StateFile is where the host cache is saved, with the journal of the sightings since the last save, if enabled
+type StateFile struct {
+	name    string
+	backup  bool
+	key     []byte
+	journal *journal.Journal
+}
+
+// save writes the host cache to the state file, and compacts the journal once all its records are included in the state
+func (f *StateFile) save(hostCache cache.HostCache) error {
+	// read before the snapshot, so that the journal is never compacted past the records included in it
+	var journalSeq uint64
+	if f.journal != nil {
+		journalSeq = f.journal.Seq()
+	}
 	appState := hostCache.ToAppState()
+	appState.JournalSeq = journalSeq
 	stateBytes, err := appState.ToJson()
-	exitOnError(err)
+	if err != nil {
+		return err
+	}
+	stateBytes, err = state.Encode(f.name, stateBytes, f.key)
+	if err != nil {
+		return err
+	}
+	// the state is saved even if the backup failed, so the journal is compacted anyway and the error only logged
+	err = state.WriteFile(f.name, stateBytes, f.backup)
+	if err != nil && !errors.Is(err, state.ErrBackup) {
+		return err
+	}
+	if f.journal != nil {
+		if err1 := f.journal.Compact(appState.JournalSeq); err1 != nil {
+			return err1
+		}
+	}
+	return err
+}
 
-	err = os.WriteFile(stateFileName, stateBytes, 0644)
-	exitOnError(err)
-	os.Exit(0)
+func (f *StateFile) getJournal() *journal.Journal {
+	if f == nil {
+		return nil
+	}
+	return f.journal
+}
+
+// openLog returns the handler writing to the configured destinations, and the log file, nil if not logging to a file
+func openLog(cfg config.Config) (*logfile.File, slog.Handler, error) {
+	var logFile *logfile.File
+	var writers []io.Writer
+	for _, destination := range cfg.LoggingConfig.Destinations {
+		switch destination {
+		case "file":
+			logRotation := *cfg.LogRotationConfig
+			var err error
+			logFile, err = logfile.Open(*cfg.LogFileName, logfile.Policy{
+				MaxSize:    int64(*logRotation.MaxSizeMB) * 1024 * 1024,
+				MaxAge:     time.Duration(*logRotation.MaxAgeHours) * time.Hour,
+				MaxBackups: int(*logRotation.MaxBackups),
+				Compress:   *logRotation.Compress,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			writers = append(writers, logFile)
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		}
+	}
+	logHandler, err := logging.NewHandler(writers, *cfg.LoggingConfig.Format, *cfg.LoggingConfig.Level)
+	return logFile, logHandler, err
 }
 
-func processArpEvent(arpEvent event.ArpEvent, hostCache cache.HostCache, filter event.ArpEventFilter, handler event.ArpEventHandler, uiApp *UIApp) {
-	if filter.IsExcluded(arpEvent.Ip.String(), arpEvent.Mac.String()) {
+func logReload(logHandler slog.Handler, ignored []string) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "Config reloaded", 0)
+	if len(ignored) > 0 {
+		record.Level = slog.LevelWarn
+		record.AddAttrs(slog.Any("RestartRequired", ignored))
+	}
+	_ = logHandler.Handle(context.Background(), record)
+}
+
+func logInfo(logHandler slog.Handler, msg string, attrs ...slog.Attr) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)
+	record.AddAttrs(attrs...)
+	_ = logHandler.Handle(context.Background(), record)
+}
+
+func logError(logHandler slog.Handler, err error) {
+	record := slog.NewRecord(time.Now(), slog.LevelError, err.Error(), 0)
+	_ = logHandler.Handle(context.Background(), record)
+}
+
+// getDetectors builds the enabled detectors, keeping those of the current detectors whose config didn't change
+func getDetectors(eventsConfig config.EventsConfig, knownVendors map[string]struct{}, bindings map[string]struct{}, current []detector) ([]detector, error) {
+	var detectors []detector
+	add := func(name string, detectorConfig any, build func() (event.Detector, error)) error {
+		for _, d := range current {
+			if d.name == name && reflect.DeepEqual(d.config, detectorConfig) {
+				detectors = append(detectors, d)
+				return nil
+			}
+		}
+		instance, err := build()
+		if err != nil {
+			return err
+		}
+		detectors = append(detectors, detector{name: name, config: detectorConfig, instance: instance})
+		return nil
+	}
+
+	anomalyConfig := *eventsConfig.AnomalyConfig
+	arpFlood, arpScan, proxyArp := *anomalyConfig.ArpFloodConfig, *anomalyConfig.ArpScanConfig, *anomalyConfig.ProxyArpConfig
+	ipFlipFlop, ipConflict := *anomalyConfig.IpFlipFlopConfig, *anomalyConfig.IpConflictConfig
+	var errs []error
+	if *arpFlood.Enabled {
+		errs = append(errs, add("arpFlood", arpFlood, func() (event.Detector, error) {
+			return event.NewArpFloodDetector(arpFlood), nil
+		}))
+	}
+	if *arpScan.Enabled {
+		errs = append(errs, add("arpScan", arpScan, func() (event.Detector, error) {
+			return event.NewArpScanDetector(arpScan), nil
+		}))
+	}
+	if *proxyArp.Enabled {
+		errs = append(errs, add("proxyArp", proxyArp, func() (event.Detector, error) {
+			return event.NewProxyArpDetector(proxyArp), nil
+		}))
+	}
+	if *ipFlipFlop.Enabled {
+		errs = append(errs, add("ipFlipFlop", ipFlipFlop, func() (event.Detector, error) {
+			return event.NewIpFlipFlopDetector(ipFlipFlop), nil
+		}))
+	}
+	if *ipConflict.Enabled {
+		errs = append(errs, add("ipConflict", ipConflict, func() (event.Detector, error) {
+			return event.NewIpConflictDetector(ipConflict), nil
+		}))
+	}
+	if *anomalyConfig.NewVendorConfig.Enabled {
+		errs = append(errs, add("newVendor", *anomalyConfig.NewVendorConfig, func() (event.Detector, error) {
+			return event.NewUnseenVendorDetector(knownVendors), nil
+		}))
+	}
+	if len(eventsConfig.VendorRules) > 0 {
+		errs = append(errs, add("vendorRules", eventsConfig.VendorRules, func() (event.Detector, error) {
+			return event.NewVendorRuleDetector(eventsConfig.VendorRules)
+		}))
+	}
+	if bindings != nil {
+		missingAfterSec := *eventsConfig.BindingsConfig.MissingAfterSec
+		errs = append(errs, add("bindings", []any{bindings, missingAfterSec}, func() (event.Detector, error) {
+			return event.NewBindingDetector(bindings, missingAfterSec, time.Now().UnixMilli()), nil
+		}))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return detectors, nil
+}
+
+func processPacket(packet gopacket.Packet, ifaceName string, localMac []byte, hostCache cache.HostCache, filter event.ArpEventFilter, handler event.ArpEventHandler, uiApp *UIApp, db *store.Store, j *journal.Journal) {
+	arpLayer := packet.Layer(layers.LayerTypeARP)
+	if arpLayer == nil {
+		// if you are using a custom BPF filter and this is not an ARP packet
+		return
+	}
+
+	arp := arpLayer.(*layers.ARP)
+	if !slices.Equal(arp.SourceHwAddress, localMac) {
+		arpEvent := event.ArpEvent{
+			Ip:        net.IP(arp.SourceProtAddress),
+			Mac:       net.HardwareAddr(arp.SourceHwAddress),
+			Ts:        time.Now().UnixMilli(),
+			TargetIp:  net.IP(arp.DstProtAddress),
+			Operation: arp.Operation,
+			Interface: ifaceName,
+		}
+		if dot1qLayer := packet.Layer(layers.LayerTypeDot1Q); dot1qLayer != nil {
+			arpEvent.Vlan = dot1qLayer.(*layers.Dot1Q).VLANIdentifier
+		}
+		processArpEvent(arpEvent, hostCache, filter, handler, uiApp, db, j)
+	}
+}
+
+func processArpEvent(arpEvent event.ArpEvent, hostCache cache.HostCache, filter event.ArpEventFilter, handler event.ArpEventHandler, uiApp *UIApp, db *store.Store, j *journal.Journal) {
+	if filter.IsExcluded(arpEvent.Ip, arpEvent.Mac, arpEvent.Ts) {
 		return
 	}
 
+	if j != nil {
+		j.Append(arpEvent)
+	}
 	extArpEvent := hostCache.Update(arpEvent)
 	handler.Handle(&extArpEvent)
 
+	if db != nil {
+		if extArpEvent.CollapsedMac != nil {
+			db.RemoveHost(arpEvent.Ip.String(), extArpEvent.CollapsedMac.String())
+		}
+		db.RecordHost(hostCache.StateItem(cache.KeyFromArpEvent(arpEvent)))
+	}
+
 	if uiApp != nil {
 		uiApp.upsertAndRefreshTable(extArpEvent)
 	}
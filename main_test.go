@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
@@ -13,11 +14,14 @@ import (
 	"github.com/ipastusi/netreact/cache"
 	"github.com/ipastusi/netreact/config"
 	"github.com/ipastusi/netreact/event"
+	"github.com/ipastusi/netreact/state"
+	"github.com/ipastusi/netreact/store"
 )
 
 var (
 	testLogFileName = "test.log"
 	yes             = true
+	no              = false
 )
 
 func Test_processArpEvents(t *testing.T) {
@@ -45,9 +49,9 @@ func Test_processArpEvents(t *testing.T) {
 	hpMac2, _ := net.ParseMAC("b4:b6:86:01:02:04")
 	dellMac, _ := net.ParseMAC("f8:bc:12:01:02:03")
 
-	excludedIPs := map[string]struct{}{"192.168.1.111": {}}
-	excludedMACs := map[string]struct{}{"31:0c:8a:00:00:01": {}}
-	excludedPairs := map[string]struct{}{"192.168.1.112,31:0c:8a:00:00:02": {}}
+	excludedIPs, _ := event.ReadIPs(strings.NewReader("192.168.1.111"))
+	excludedMACs, _ := event.ReadMACs(strings.NewReader("31:0c:8a:00:00:01"))
+	excludedPairs, _ := event.ReadPairs(strings.NewReader("192.168.1.112,31:0c:8a:00:00:02"))
 	filter := event.NewArpEventFilter(excludedIPs, excludedMACs, excludedPairs)
 
 	hostCache := cache.NewHostCache()
@@ -60,6 +64,7 @@ func Test_processArpEvents(t *testing.T) {
 		NewUnexpected:       &yes,
 		NewIpForMac:         &yes,
 		NewMacForIp:         &yes,
+		NewRandomizedMac:    &no,
 	}
 	handler := event.NewArpEventHandler(logHandler, eventDir, eventTypeConfig, eventTypeConfig, "192.168.1.0/24", ipToMac, macToIp)
 
@@ -85,9 +90,10 @@ func Test_processArpEvents(t *testing.T) {
 		{event.ArpEvent{Ip: net.ParseIP("192.168.1.112"), Mac: excludedMac, Ts: time.Now().UnixMilli() + 11}, 7, 0, "Unknown", []event.Type{}, true},
 	}
 
+	var expectedEventRecords int
 	for i, e := range events {
 		// process test event
-		processArpEvent(e.arpEvent, hostCache, filter, handler, nil)
+		processArpEvent(e.arpEvent, hostCache, filter, handler, nil, nil, nil)
 
 		// cache checks
 		hostCacheSize := len(hostCache.Items)
@@ -125,21 +131,32 @@ func Test_processArpEvents(t *testing.T) {
 			t.Fatal("error reading execution log:", err)
 		}
 
-		testLog := string(testLogBytes)
-		testLogLines := strings.Split(testLog, "\n")
-		testlogLineCount := len(testLogLines)
-		expectedLineCount := i + 2
-		if testlogLineCount != expectedLineCount {
-			t.Fatalf("unexpected number entries in test log file, expected: %v, got: %v", expectedLineCount, testlogLineCount)
+		var packetRecords, eventRecords []string
+		for _, line := range strings.Split(strings.TrimSpace(string(testLogBytes)), "\n") {
+			if strings.Contains(line, "ARP packet received") {
+				packetRecords = append(packetRecords, line)
+			} else if strings.Contains(line, "Event generated") {
+				eventRecords = append(eventRecords, line)
+			}
+		}
+		if len(packetRecords) != i+1 {
+			t.Fatalf("unexpected number of packet records in test log file, expected: %v, got: %v", i+1, len(packetRecords))
+		}
+		expectedEventRecords += len(e.expectedEventCodes)
+		if len(eventRecords) != expectedEventRecords {
+			t.Fatalf("unexpected number of event records in test log file, expected: %v, got: %v", expectedEventRecords, len(eventRecords))
 		}
 
-		lastLogRecord := testLogLines[len(testLogLines)-2]
+		lastLogRecord := packetRecords[len(packetRecords)-1]
 		if !strings.Contains(lastLogRecord, e.arpEvent.Ip.String()) {
 			t.Fatal("IP address not found in test log for iteration:", i)
 		}
 		if !strings.Contains(lastLogRecord, e.arpEvent.Mac.String()) {
 			t.Fatal("MAC address not found in test log for iteration:", i)
 		}
+		if !e.excluded && !strings.Contains(lastLogRecord, e.expectedMacVendor) {
+			t.Fatal("MAC vendor not found in test log for iteration:", i)
+		}
 
 		// check event files
 		var allEventCodes []event.Type
@@ -184,6 +201,54 @@ func Test_processArpEvents(t *testing.T) {
 	janitor.CleanupEventFiles()
 }
 
+func Test_processArpEventsRandomizedMac(t *testing.T) {
+	t.Parallel()
+
+	eventDir := t.TempDir()
+	randomizedMac, _ := net.ParseMAC("31:0c:8a:cb:8f:ab")
+	globalMac, _ := net.ParseMAC("2c:cf:67:0c:6c:a4")
+	hostCache := cache.NewHostCache()
+	ipToMac, macToIp := hostCache.IpAndMacMaps()
+	eventTypeConfig := config.EventTypeConfig{
+		Any:                 &no,
+		NewLinkLocalUnicast: &no,
+		NewUnspecified:      &no,
+		NewBroadcast:        &no,
+		NewUnexpected:       &no,
+		NewIpForMac:         &no,
+		NewMacForIp:         &no,
+		NewRandomizedMac:    &yes,
+	}
+	handler := event.NewArpEventHandler(slog.DiscardHandler, eventDir, eventTypeConfig, eventTypeConfig, "192.168.1.0/24", ipToMac, macToIp)
+	filter := event.NewArpEventFilter()
+
+	ts := time.Now().UnixMilli()
+	events := []struct {
+		arpEvent           event.ArpEvent
+		expectedEventCodes []event.Type
+	}{
+		{event.ArpEvent{Ip: net.ParseIP("192.168.1.200"), Mac: randomizedMac, Ts: ts}, []event.Type{event.NewRandomizedMacPacket, event.NewRandomizedMacHost}},
+		{event.ArpEvent{Ip: net.ParseIP("192.168.1.200"), Mac: randomizedMac, Ts: ts + 1}, []event.Type{event.NewRandomizedMacPacket}},
+		{event.ArpEvent{Ip: net.ParseIP("192.168.1.100"), Mac: globalMac, Ts: ts + 2}, nil},
+	}
+	for _, e := range events {
+		processArpEvent(e.arpEvent, hostCache, filter, handler, nil, nil, nil)
+		eventFiles, _ := filepath.Glob(filepath.Join(eventDir, fmt.Sprintf("netreact-%v-*.json", e.arpEvent.Ts)))
+		var eventCodes []event.Type
+		for _, eventFile := range eventFiles {
+			var eventCode event.Type
+			if _, err := fmt.Sscanf(filepath.Base(eventFile), fmt.Sprintf("netreact-%v-%%d.json", e.arpEvent.Ts), &eventCode); err != nil {
+				t.Fatal("unexpected event file:", eventFile)
+			}
+			eventCodes = append(eventCodes, eventCode)
+		}
+		slices.Sort(eventCodes)
+		if !slices.Equal(eventCodes, e.expectedEventCodes) {
+			t.Fatalf("unexpected events for %v, expected: %v, got: %v", e.arpEvent.Mac, e.expectedEventCodes, eventCodes)
+		}
+	}
+}
+
 func getLogHandler(t *testing.T) slog.Handler {
 	logFile, err := os.OpenFile(testLogFileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
 	if err != nil {
@@ -191,3 +256,38 @@ func getLogHandler(t *testing.T) slog.Handler {
 	}
 	return slog.NewJSONHandler(logFile, nil)
 }
+
+func Test_loadFromDatabase(t *testing.T) {
+	t.Parallel()
+
+	db, err := store.Open(filepath.Join(t.TempDir(), "netreact.db"), 60, 0)
+	if err != nil {
+		t.Fatal("error opening store:", err)
+	}
+	defer func() { _ = db.Close() }()
+	stored := state.Item{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1749913040000, LastTs: 1749913045000, Count: 5}
+	dbState := state.NewAppState()
+	dbState.Items = []state.Item{stored}
+	if err = db.Import(dbState); err != nil {
+		t.Fatal("error importing app state:", err)
+	}
+
+	// the database copy of the first host is newer, the second host is only in the state file
+	fileState := state.NewAppState()
+	fileState.Items = []state.Item{
+		{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1749913040000, LastTs: 1749913041000, Count: 1},
+		{Ip: "10.0.0.2", Mac: "00:00:00:04:05:06", FirstTs: 1749913042000, LastTs: 1749913042000, Count: 1},
+	}
+	hostCache, imported, err := loadFromDatabase(db, cache.FromAppState(fileState))
+	if err != nil || imported != 1 {
+		t.Fatalf("unexpected result, imported: %v, error: %v", imported, err)
+	}
+	if host := hostCache.Host(cache.KeyFromIpMac(stored.Ip, stored.Mac)); host.Count != stored.Count || len(hostCache.Items) != 2 {
+		t.Fatal("unexpected hosts:", hostCache.Items)
+	}
+
+	appState, err := db.LoadAppState()
+	if err != nil || len(appState.Items) != 2 {
+		t.Fatalf("unexpected hosts in database: %v, error: %v", appState.Items, err)
+	}
+}
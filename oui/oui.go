@@ -4,6 +4,7 @@ import (
 	"cmp"
 	_ "embed"
 	"encoding/hex"
+	"fmt"
 	"net"
 	"slices"
 	"strings"
@@ -21,6 +22,32 @@ var ouiRaw string
 
 var ouiList = strings.Split(ouiRaw, "\n")
 
+// UnknownVendor is returned by MacToVendor if the OUI is not found
+const UnknownVendor = "Unknown"
+
+// IsLocallyAdministered reports whether the MAC address has the locally administered bit set, as is the case for
+// randomized MAC addresses used by modern phones, but also for virtual machines and containers
+func IsLocallyAdministered(mac net.HardwareAddr) bool {
+	return len(mac) > 0 && mac[0]&0x02 != 0
+}
+
+// IsMulticast reports whether the MAC address has the multicast bit set, which is not expected for ARP packet sources
+func IsMulticast(mac net.HardwareAddr) bool {
+	return len(mac) > 0 && mac[0]&0x01 != 0
+}
+
+// ParsePrefix parses a MAC address prefix of 1 to 6 bytes, e.g. an OUI like b4:b6:86, b4-b6-86 or b4b686
+func ParsePrefix(prefix string) ([]byte, error) {
+	digits := strings.NewReplacer(":", "", "-", "", ".", "").Replace(prefix)
+	bytes, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC prefix %v: %v", prefix, err)
+	} else if len(bytes) == 0 || len(bytes) > 6 {
+		return nil, fmt.Errorf("invalid MAC prefix %v: expected 1 to 6 bytes, got %v", prefix, len(bytes))
+	}
+	return bytes, nil
+}
+
 func MacToVendor(mac net.HardwareAddr) string {
 	oui := hex.EncodeToString(mac[:3])
 	i, ok := slices.BinarySearchFunc(ouiList, oui, func(str, target string) int {
@@ -28,7 +55,7 @@ func MacToVendor(mac net.HardwareAddr) string {
 	})
 
 	if !ok {
-		return "Unknown"
+		return UnknownVendor
 	}
 
 	vendorName := ouiList[i][7:]
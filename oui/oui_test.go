@@ -3,6 +3,7 @@ package oui_test
 import (
 	"fmt"
 	"net"
+	"slices"
 	"testing"
 
 	"github.com/ipastusi/netreact/oui"
@@ -42,3 +43,64 @@ func Test_MacToVendor(t *testing.T) {
 		})
 	}
 }
+
+func Test_MacType(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]struct {
+		mac                 string
+		locallyAdministered bool
+		multicast           bool
+	}{
+		"Universally administered": {"2c:cf:67:0c:6c:a4", false, false},
+		"Randomized":               {"da:a1:19:00:00:01", true, false},
+		"Docker":                   {"02:42:ac:11:00:02", true, false},
+		"Multicast":                {"01:00:5e:00:00:01", false, true},
+		"Broadcast":                {"ff:ff:ff:ff:ff:ff", true, true},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mac, _ := net.ParseMAC(d.mac)
+			if la := oui.IsLocallyAdministered(mac); la != d.locallyAdministered {
+				t.Fatalf("unexpected locally administered flag for MAC %v, expected: %v, got: %v", mac, d.locallyAdministered, la)
+			}
+			if mc := oui.IsMulticast(mac); mc != d.multicast {
+				t.Fatalf("unexpected multicast flag for MAC %v, expected: %v, got: %v", mac, d.multicast, mc)
+			}
+		})
+	}
+}
+
+func Test_ParsePrefix(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]struct {
+		prefix   string
+		expected []byte
+		ok       bool
+	}{
+		"colons":      {"b4:b6:86", []byte{0xb4, 0xb6, 0x86}, true},
+		"dashes":      {"B4-B6-86", []byte{0xb4, 0xb6, 0x86}, true},
+		"plain":       {"b4b686", []byte{0xb4, 0xb6, 0x86}, true},
+		"MA-S prefix": {"70:b3:d5:00:0f", []byte{0x70, 0xb3, 0xd5, 0x00, 0x0f}, true},
+		"empty":       {"", nil, false},
+		"too long":    {"b4:b6:86:00:00:00:01", nil, false},
+		"odd length":  {"b4b", nil, false},
+		"invalid":     {"xx:b6:86", nil, false},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			prefix, err := oui.ParsePrefix(d.prefix)
+			if (err == nil) != d.ok {
+				t.Fatalf("unexpected result for prefix %v, expected ok: %v, got error: %v", d.prefix, d.ok, err)
+			}
+			if !slices.Equal(prefix, d.expected) {
+				t.Fatalf("unexpected prefix for %v, expected: %v, got: %v", d.prefix, d.expected, prefix)
+			}
+		})
+	}
+}
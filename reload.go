@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/ipastusi/netreact/cache"
+	"github.com/ipastusi/netreact/cli"
+	"github.com/ipastusi/netreact/config"
+	"github.com/ipastusi/netreact/event"
+	"github.com/ipastusi/netreact/store"
+)
+
+// pipeline is the part of the packet processing built from the config, and rebuilt on reload: the exclusion filter, the
+// event handler with its detectors and sinks, and the event file janitor. The detectors with an unchanged config are
+// carried over to the new pipeline, so that they keep their learned baselines and history.
+type pipeline struct {
+	filter    event.ArpEventFilter
+	handler   event.ArpEventHandler
+	janitor   *event.EventJanitor
+	detectors []detector
+}
+
+// detector is a detector along with the config it was built from
+type detector struct {
+	name     string
+	config   any
+	instance event.Detector
+}
+
+func loadConfig(flags cli.Flags) (config.Config, error) {
+	var cfgData []byte
+	if flags.ConfigFileName != nil && *flags.ConfigFileName != "" {
+		var err error
+		if cfgData, err = os.ReadFile(*flags.ConfigFileName); err != nil {
+			return config.Config{}, err
+		}
+	}
+	return config.GetConfig(cfgData, flags.IfaceName, flags.LogFileName, flags.PromiscMode, flags.StateFileName)
+}
+
+// newPipeline reads the exclusion, inclusion and bindings files and builds the pipeline, without starting the janitor.
+// The current detectors are kept if their config didn't change.
+func newPipeline(cfg config.Config, logHandler slog.Handler, hostCache cache.HostCache, db *store.Store, current []detector) (pipeline, error) {
+	eventsConfig := *cfg.EventsConfig
+	exclude, include := eventsConfig.ExcludeConfig, eventsConfig.IncludeConfig
+	excluded, err := readAddressLists(exclude.IpFile, exclude.MacFile, exclude.IpMacFile)
+	if err != nil {
+		return pipeline{}, err
+	}
+	included, err := readAddressLists(include.IpFile, include.MacFile, include.IpMacFile)
+	if err != nil {
+		return pipeline{}, err
+	}
+	excludeEvents, err := newEventFilter(*exclude.EventsConfig, false)
+	if err != nil {
+		return pipeline{}, err
+	}
+	includeEvents, err := newEventFilter(*include.EventsConfig, true)
+	if err != nil {
+		return pipeline{}, err
+	}
+	bindings, err := readListFile(eventsConfig.BindingsConfig.File, event.ReadBindings)
+	if err != nil {
+		return pipeline{}, err
+	}
+
+	var janitor *event.EventJanitor
+	if *eventsConfig.AutoCleanupDelaySec > 0 {
+		j, err := event.NewEventJanitor(logHandler, *eventsConfig.Directory, *eventsConfig.AutoCleanupDelaySec)
+		if err != nil {
+			return pipeline{}, err
+		}
+		janitor = &j
+	}
+
+	filter := event.NewArpEventFilter(excluded...).WithIncluded(included...)
+	ipToMac, macToIp := hostCache.IpAndMacMaps()
+	handler := event.NewArpEventHandler(logHandler, *eventsConfig.Directory, *eventsConfig.PacketEventConfig,
+		*eventsConfig.HostEventConfig, *eventsConfig.ExpectedCidrRange, ipToMac, macToIp)
+	detectors, err := getDetectors(eventsConfig, hostCache.Vendors(), bindings, current)
+	if err != nil {
+		return pipeline{}, err
+	}
+	var instances []event.Detector
+	for _, d := range detectors {
+		instances = append(instances, d.instance)
+	}
+	handler = handler.WithDetectors(instances...).WithRandomizedMacConfig(*cfg.RandomizedMacConfig).
+		WithPacketLog(*cfg.LoggingConfig.Packets).WithEventFilters(excludeEvents, includeEvents)
+	if db != nil {
+		handler = handler.WithSinks(db)
+	}
+	return pipeline{filter: filter, handler: handler, janitor: janitor, detectors: detectors}, nil
+}
+
+// readAddressLists reads those of the IP, MAC and IP-MAC pair files which are configured
+func readAddressLists(ipFile *string, macFile *string, ipMacFile *string) ([]event.AddressList, error) {
+	files := []struct {
+		fileName *string
+		read     func(reader io.Reader) (event.AddressList, error)
+	}{
+		{ipFile, event.ReadIPs},
+		{macFile, event.ReadMACs},
+		{ipMacFile, event.ReadPairs},
+	}
+	var lists []event.AddressList
+	for _, file := range files {
+		if file.fileName == nil {
+			continue
+		}
+		list, err := readListFile(file.fileName, file.read)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// newEventFilter builds the filter of the events.exclude.events or events.include.events section
+func newEventFilter(filterConfig config.EventFilterConfig, include bool) (event.EventFilter, error) {
+	lists, err := readAddressLists(filterConfig.IpFile, filterConfig.MacFile, filterConfig.IpMacFile)
+	if err != nil {
+		return event.EventFilter{}, err
+	}
+	var exemptTypes []event.Type
+	for _, code := range filterConfig.ExemptTypes {
+		eventType, err := event.TypeFromCode(code)
+		if err != nil {
+			return event.EventFilter{}, err
+		}
+		exemptTypes = append(exemptTypes, eventType)
+	}
+	hosts := event.NewArpEventFilter(lists...)
+	if include {
+		hosts = event.NewArpEventFilter().WithIncluded(lists...)
+	}
+	return event.NewEventFilter(hosts, exemptTypes...), nil
+}
+
+// readListFile reads the exclusion or bindings file, if configured
+func readListFile[T any](fileName *string, read func(reader io.Reader) (T, error)) (T, error) {
+	var list T
+	if fileName == nil {
+		return list, nil
+	}
+	file, err := os.Open(*fileName)
+	if err != nil {
+		return list, err
+	}
+	defer func() { _ = file.Close() }()
+	if list, err = read(file); err != nil {
+		return list, fmt.Errorf("invalid file %v: %w", *fileName, err)
+	}
+	return list, nil
+}
+
+func (p pipeline) start() {
+	if p.janitor != nil {
+		p.janitor.Start()
+	}
+}
+
+func (p pipeline) stop() {
+	if p.janitor != nil {
+		p.janitor.Stop()
+	}
+}
+
+// reloadConfig re-reads the config and builds a new pipeline from it, keeping the detectors of the current pipeline whose
+// config didn't change. Only the events, randomized MAC and per-packet logging settings are applied, the rest takes effect
+// after a restart, so it's returned as the list of ignored sections. The current config is returned unchanged if the new
+// one is invalid.
+func reloadConfig(flags cli.Flags, cfg config.Config, current pipeline, logHandler slog.Handler, hostCache cache.HostCache, db *store.Store) (config.Config, pipeline, []string, error) {
+	reloaded, err := loadConfig(flags)
+	if err != nil {
+		return cfg, pipeline{}, nil, fmt.Errorf("config not reloaded: %w", err)
+	}
+	p, err := newPipeline(reloaded, logHandler, hostCache, db, current.detectors)
+	if err != nil {
+		return cfg, pipeline{}, nil, fmt.Errorf("config not reloaded: %w", err)
+	}
+
+	// logging.packets is reloadable, the rest of the logging section isn't
+	reloadedLogging := *reloaded.LoggingConfig
+	reloadedLogging.Packets = cfg.LoggingConfig.Packets
+	sections := []struct {
+		name     string
+		current  any
+		reloaded any
+	}{
+		{"interface", cfg.IfaceName, reloaded.IfaceName},
+		{"log", cfg.LogFileName, reloaded.LogFileName},
+		{"logRotation", cfg.LogRotationConfig, reloaded.LogRotationConfig},
+		{"logging", *cfg.LoggingConfig, reloadedLogging},
+		{"stateFile", cfg.StateFileName, reloaded.StateFileName},
+		{"state", cfg.StateConfig, reloaded.StateConfig},
+		{"database", cfg.DatabaseConfig, reloaded.DatabaseConfig},
+		{"bpfFilter", cfg.BpfFilter, reloaded.BpfFilter},
+		{"promiscMode", cfg.PromiscMode, reloaded.PromiscMode},
+		{"ui", cfg.Ui, reloaded.Ui},
+		{"reload", cfg.ReloadConfig, reloaded.ReloadConfig},
+		{"hosts", cfg.HostsConfig, reloaded.HostsConfig},
+	}
+	var ignored []string
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.current, section.reloaded) {
+			ignored = append(ignored, section.name)
+		}
+	}
+
+	cfg.EventsConfig = reloaded.EventsConfig
+	cfg.RandomizedMacConfig = reloaded.RandomizedMacConfig
+	logging := *cfg.LoggingConfig
+	logging.Packets = reloaded.LoggingConfig.Packets
+	cfg.LoggingConfig = &logging
+	return cfg, p, ignored, nil
+}
+
+// configWatcher polls the config file, and the exclusion, inclusion and bindings files it refers to, for changes
+type configWatcher struct {
+	fileNames []string
+	modTimes  map[string]time.Time
+}
+
+func newConfigWatcher(flags cli.Flags, cfg config.Config) *configWatcher {
+	w := &configWatcher{}
+	w.watch(flags, cfg)
+	return w
+}
+
+// watch replaces the watched files, e.g. after a reload changed the exclusion files
+func (w *configWatcher) watch(flags cli.Flags, cfg config.Config) {
+	exclude, include := cfg.EventsConfig.ExcludeConfig, cfg.EventsConfig.IncludeConfig
+	fileNames := []*string{
+		flags.ConfigFileName,
+		exclude.IpFile, exclude.MacFile, exclude.IpMacFile,
+		exclude.EventsConfig.IpFile, exclude.EventsConfig.MacFile, exclude.EventsConfig.IpMacFile,
+		include.IpFile, include.MacFile, include.IpMacFile,
+		include.EventsConfig.IpFile, include.EventsConfig.MacFile, include.EventsConfig.IpMacFile,
+		cfg.EventsConfig.BindingsConfig.File,
+	}
+	w.fileNames = nil
+	for _, fileName := range fileNames {
+		if fileName != nil && *fileName != "" {
+			w.fileNames = append(w.fileNames, *fileName)
+		}
+	}
+	w.modTimes = w.stat()
+}
+
+func (w *configWatcher) stat() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	for _, fileName := range w.fileNames {
+		// a missing file counts as a change too, once it appears again
+		if info, err := os.Stat(fileName); err == nil {
+			modTimes[fileName] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// changed reports whether any of the watched files changed since they were last watched. The change isn't consumed until
+// the next watch, so that a failed reload is retried, e.g. once a missing file referred to by the new config is created.
+func (w *configWatcher) changed() bool {
+	return !reflect.DeepEqual(w.stat(), w.modTimes)
+}
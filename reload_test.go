@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ipastusi/netreact/cache"
+	"github.com/ipastusi/netreact/cli"
+	"github.com/ipastusi/netreact/config"
+	"github.com/ipastusi/netreact/event"
+)
+
+func Test_reloadConfig(t *testing.T) {
+	t.Parallel()
+
+	// paths in the config are relative to the working directory
+	pwd, _ := os.Getwd()
+	dir, err := filepath.Rel(pwd, t.TempDir())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	iface, _ := net.InterfaceByIndex(1)
+	configFileName := filepath.Join(dir, "netreact.yaml")
+	ipFileName := filepath.Join(dir, "exclude-ips.txt")
+	writeFile := func(fileName string, data string) {
+		t.Helper()
+		if err := os.WriteFile(fileName, []byte(data), 0644); err != nil {
+			t.Fatal("error writing file:", err)
+		}
+	}
+	writeFile(ipFileName, "192.168.1.1\n")
+	writeFile(configFileName, "ui: false\nevents:\n  directory: "+dir+"\n  exclude:\n    ipFile: "+ipFileName+"\n")
+
+	empty, logFileName := "", filepath.Join(dir, "netreact.log")
+	flags := cli.Flags{ConfigFileName: &configFileName, IfaceName: &iface.Name, LogFileName: &logFileName, StateFileName: &empty}
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		t.Fatal("unexpected error loading config:", err)
+	}
+	logHandler := slog.DiscardHandler
+	hostCache := cache.NewHostCache()
+	current, err := newPipeline(cfg, logHandler, hostCache, nil, nil)
+	if err != nil {
+		t.Fatal("unexpected error building pipeline:", err)
+	}
+	watcher := newConfigWatcher(flags, cfg)
+
+	// the exclusion file changes, and so does a setting requiring a restart
+	writeFile(ipFileName, "192.168.1.2\n")
+	writeFile(configFileName, "ui: true\nevents:\n  directory: "+dir+"\n  exclude:\n    ipFile: "+ipFileName+"\n")
+	// modification times might not be fine-grained enough to tell the writes apart
+	future := time.Now().Add(time.Minute)
+	if err = os.Chtimes(ipFileName, future, future); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if !watcher.changed() {
+		t.Fatal("change not detected")
+	}
+
+	reloaded, p, ignored, err := reloadConfig(flags, cfg, current, logHandler, hostCache, nil)
+	if err != nil {
+		t.Fatal("unexpected error reloading config:", err)
+	}
+	mac, _ := net.ParseMAC("00:00:00:01:02:03")
+	now := time.Now().UnixMilli()
+	if p.filter.IsExcluded(net.ParseIP("192.168.1.1"), mac, now) || !p.filter.IsExcluded(net.ParseIP("192.168.1.2"), mac, now) {
+		t.Fatal("exclusion file not reloaded")
+	}
+	if !slices.Equal(ignored, []string{"ui"}) || *reloaded.Ui {
+		t.Fatal("unexpected settings requiring a restart:", ignored)
+	}
+	// the change is only consumed once the reloaded config is watched
+	if !watcher.changed() {
+		t.Fatal("change consumed before the reload")
+	}
+	watcher.watch(flags, reloaded)
+	if watcher.changed() {
+		t.Fatal("change not consumed after the reload")
+	}
+
+	// an invalid config keeps the current one
+	writeFile(configFileName, "events:\n  expectedCidrRange: 10.0.0.0/33\n")
+	kept, _, _, err := reloadConfig(flags, reloaded, p, logHandler, hostCache, nil)
+	if err == nil {
+		t.Fatal("no error on invalid config")
+	}
+	if kept.EventsConfig != reloaded.EventsConfig {
+		t.Fatal("current config not kept")
+	}
+}
+
+func Test_reloadConfigKeepsDetectors(t *testing.T) {
+	t.Parallel()
+
+	pwd, _ := os.Getwd()
+	dir, err := filepath.Rel(pwd, t.TempDir())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	iface, _ := net.InterfaceByIndex(1)
+	configFileName := filepath.Join(dir, "netreact.yaml")
+	writeConfig := func(minTargets int) {
+		t.Helper()
+		cfgData := fmt.Sprintf("ui: false\nevents:\n  directory: %v\n  anomaly:\n    arpFlood:\n      enabled: true\n"+
+			"    arpScan:\n      enabled: true\n      minTargets: %v\n", dir, minTargets)
+		if err := os.WriteFile(configFileName, []byte(cfgData), 0644); err != nil {
+			t.Fatal("error writing file:", err)
+		}
+	}
+	writeConfig(20)
+
+	empty, logFileName := "", filepath.Join(dir, "netreact.log")
+	flags := cli.Flags{ConfigFileName: &configFileName, IfaceName: &iface.Name, LogFileName: &logFileName, StateFileName: &empty}
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		t.Fatal("unexpected error loading config:", err)
+	}
+	hostCache := cache.NewHostCache()
+	current, err := newPipeline(cfg, slog.DiscardHandler, hostCache, nil, nil)
+	if err != nil {
+		t.Fatal("unexpected error building pipeline:", err)
+	}
+
+	// only the arpScan section changes
+	writeConfig(10)
+	_, p, _, err := reloadConfig(flags, cfg, current, slog.DiscardHandler, hostCache, nil)
+	if err != nil {
+		t.Fatal("unexpected error reloading config:", err)
+	}
+	if len(p.detectors) != 2 {
+		t.Fatal("unexpected number of detectors:", len(p.detectors))
+	}
+	for i, name := range []string{"arpFlood", "arpScan"} {
+		if p.detectors[i].name != name {
+			t.Fatalf("unexpected detector, expected: %v, got: %v", name, p.detectors[i].name)
+		}
+		kept := p.detectors[i].instance == current.detectors[i].instance
+		if kept != (name == "arpFlood") {
+			t.Fatalf("unexpected %v detector reuse: %v", name, kept)
+		}
+	}
+}
+
+func Test_newPipelineScopes(t *testing.T) {
+	t.Parallel()
+
+	pwd, _ := os.Getwd()
+	dir, err := filepath.Rel(pwd, t.TempDir())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	iface, _ := net.InterfaceByIndex(1)
+	includeFileName := filepath.Join(dir, "include-ips.txt")
+	quietFileName := filepath.Join(dir, "quiet-ips.txt")
+	for fileName, data := range map[string]string{includeFileName: "192.168.1.0/24\n", quietFileName: "192.168.1.10\n"} {
+		if err = os.WriteFile(fileName, []byte(data), 0644); err != nil {
+			t.Fatal("error writing file:", err)
+		}
+	}
+	cfgData := "ui: false\nevents:\n  directory: " + dir + "\n  packet:\n    any: true\n" +
+		"  exclude:\n    events:\n      ipFile: " + quietFileName + "\n      exemptTypes: [200]\n" +
+		"  include:\n    ipFile: " + includeFileName + "\n"
+	empty, logFileName := "", filepath.Join(dir, "netreact.log")
+	cfg, err := config.GetConfig([]byte(cfgData), &iface.Name, &logFileName, &yes, &empty)
+	if err != nil {
+		t.Fatal("unexpected error loading config:", err)
+	}
+	hostCache := cache.NewHostCache()
+	p, err := newPipeline(cfg, slog.DiscardHandler, hostCache, nil, nil)
+	if err != nil {
+		t.Fatal("unexpected error building pipeline:", err)
+	}
+
+	mac, _ := net.ParseMAC("00:00:00:01:02:03")
+	now := time.Now().UnixMilli()
+	for i, ip := range []string{"192.168.1.1", "192.168.1.10", "10.0.0.1"} {
+		processArpEvent(event.ArpEvent{Ip: net.ParseIP(ip), Mac: mac, Ts: now + int64(i)}, hostCache, p.filter, p.handler, nil, nil, nil)
+	}
+
+	// the host outside the included range isn't tracked, the quiet one is tracked, but only reports new hosts
+	if len(hostCache.Items) != 2 {
+		t.Fatal("unexpected number of tracked hosts:", len(hostCache.Items))
+	}
+	for eventType, expected := range map[event.Type]int{event.NewPacket: 1, event.NewHost: 2} {
+		eventFiles, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("netreact-*-%v.json", eventType)))
+		if len(eventFiles) != expected {
+			t.Fatalf("unexpected number of %v events, expected: %v, got: %v", eventType, expected, len(eventFiles))
+		}
+	}
+}
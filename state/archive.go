@@ -0,0 +1,54 @@
+package state
+
+import (
+	"cmp"
+	"errors"
+	"os"
+	"slices"
+)
+
+// Archive merges the items into the archive file, replacing the archived items of the same IP-MAC pairs. The archive
+// is a regular state file, so it can be loaded or inspected like any other state file, and can be compressed too. It's
+// encrypted with the key, if not nil, like the state file.
+func Archive(fileName string, items []Item, key []byte) error {
+	appState := NewAppState()
+	stateBytes, err := os.ReadFile(fileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	} else if err == nil {
+		if stateBytes, err = Decode(stateBytes, key); err != nil {
+			return err
+		}
+		if appState, err = Load(stateBytes); err != nil {
+			return err
+		}
+	}
+
+	type ipMac struct {
+		ip  string
+		mac string
+	}
+	archived := map[ipMac]int{}
+	for i, item := range appState.Items {
+		archived[ipMac{item.Ip, item.Mac}] = i
+	}
+	for _, item := range items {
+		if i, ok := archived[ipMac{item.Ip, item.Mac}]; ok {
+			appState.Items[i] = item
+		} else {
+			appState.Items = append(appState.Items, item)
+		}
+	}
+	slices.SortFunc(appState.Items, func(a, b Item) int {
+		return cmp.Compare(a.FirstTs, b.FirstTs)
+	})
+
+	data, err := appState.ToJson()
+	if err == nil {
+		data, err = Encode(fileName, data, key)
+	}
+	if err != nil {
+		return err
+	}
+	return WriteFile(fileName, data, false)
+}
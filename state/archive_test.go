@@ -0,0 +1,85 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ipastusi/netreact/state"
+)
+
+func Test_Archive(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "archive.json")
+	host1 := state.Item{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1749913040850, LastTs: 1749913040850, Count: 1}
+	host2 := state.Item{Ip: "10.0.0.2", Mac: "00:00:00:04:05:06", FirstTs: 1749913040852, LastTs: 1749913040852, Count: 1}
+	if err := state.Archive(fileName, []state.Item{host2}, nil); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// archived again after being seen and pruned once more
+	host2.LastTs, host2.Count = 1749913040900, 2
+	if err := state.Archive(fileName, []state.Item{host2, host1}, nil); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	stateBytes, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal("error reading archive:", err)
+	}
+	appState, err := state.Load(stateBytes)
+	if err != nil {
+		t.Fatal("unexpected error loading archive:", err)
+	}
+	expectedItems := []state.Item{host1, host2}
+	if diff := cmp.Diff(expectedItems, appState.Items); diff != "" {
+		t.Fatal("unexpected archived items:", diff)
+	}
+}
+
+func Test_ArchiveEncrypted(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "archive.json")
+	key := make([]byte, 32)
+	host1 := state.Item{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1749913040850, LastTs: 1749913040850, Count: 1}
+	host2 := state.Item{Ip: "10.0.0.2", Mac: "00:00:00:04:05:06", FirstTs: 1749913040852, LastTs: 1749913040852, Count: 1}
+	for _, item := range []state.Item{host1, host2} {
+		if err := state.Archive(fileName, []state.Item{item}, key); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	stateBytes, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal("error reading archive:", err)
+	}
+	if _, err = state.Load(stateBytes); err == nil {
+		t.Fatal("archive not encrypted")
+	}
+	if stateBytes, err = state.Decode(stateBytes, key); err != nil {
+		t.Fatal("unexpected error decoding archive:", err)
+	}
+	appState, err := state.Load(stateBytes)
+	if err != nil {
+		t.Fatal("unexpected error loading archive:", err)
+	}
+	if diff := cmp.Diff([]state.Item{host1, host2}, appState.Items); diff != "" {
+		t.Fatal("unexpected archived items:", diff)
+	}
+}
+
+func Test_ArchiveInvalid(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "archive.json")
+	if err := os.WriteFile(fileName, []byte(`{"items": "none"}`), 0644); err != nil {
+		t.Fatal("error writing archive:", err)
+	}
+	item := state.Item{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1749913040850, LastTs: 1749913040850, Count: 1}
+	if err := state.Archive(fileName, []state.Item{item}, nil); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
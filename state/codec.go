@@ -0,0 +1,147 @@
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const KeySize = 32
+
+var (
+	gzipMagic      = []byte{0x1f, 0x8b}
+	zstdMagic      = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	encryptedMagic = []byte("NRENC1")
+
+	ErrEncrypted = errors.New("state file is encrypted, but no encryption key is configured")
+	ErrWrongKey  = errors.New("unable to decrypt state file, wrong encryption key or corrupted file")
+)
+
+// ParseKey decodes a 256-bit AES key, encoded as hex or base64, e.g. generated with openssl rand -hex 32
+func ParseKey(text string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	key, err := hex.DecodeString(text)
+	if err != nil {
+		key, err = base64.StdEncoding.DecodeString(text)
+	}
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key should be %v bytes, encoded as hex or base64", KeySize)
+	}
+	return key, nil
+}
+
+// ReadKey reads the encryption key from a file or an environment variable, in the format accepted by ParseKey. Returns
+// nil if neither is given, nil or empty.
+func ReadKey(keyFile *string, keyEnv *string) ([]byte, error) {
+	if keyFile != nil && *keyFile != "" {
+		keyBytes, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return ParseKey(string(keyBytes))
+	}
+	if keyEnv != nil && *keyEnv != "" {
+		keyText, ok := os.LookupEnv(*keyEnv)
+		if !ok {
+			return nil, fmt.Errorf("state encryption key environment variable not set: %v", *keyEnv)
+		}
+		return ParseKey(keyText)
+	}
+	return nil, nil
+}
+
+// Encode compresses the state according to the file name extension, gzip for .gz and zstd for .zst, and encrypts it
+// with AES-GCM, if the key is not nil
+func Encode(fileName string, data []byte, key []byte) ([]byte, error) {
+	var err error
+	switch filepath.Ext(fileName) {
+	case ".gz":
+		var buf bytes.Buffer
+		gzipWriter := gzip.NewWriter(&buf)
+		if _, err = gzipWriter.Write(data); err == nil {
+			err = gzipWriter.Close()
+		}
+		data = buf.Bytes()
+	case ".zst":
+		var zstdEncoder *zstd.Encoder
+		if zstdEncoder, err = zstd.NewWriter(nil); err == nil {
+			data = zstdEncoder.EncodeAll(data, nil)
+			err = zstdEncoder.Close()
+		}
+	}
+	if err != nil || key == nil {
+		return data, err
+	}
+
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encrypted := append(bytes.Clone(encryptedMagic), nonce...)
+	return aead.Seal(encrypted, nonce, data, encryptedMagic), nil
+}
+
+// Decode reverses Encode, detecting encryption and compression by their magic bytes rather than the file name, so a
+// state file can be renamed or compressed by other tools
+func Decode(data []byte, key []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, encryptedMagic) {
+		if key == nil {
+			return nil, ErrEncrypted
+		}
+		aead, err := newAead(key)
+		if err != nil {
+			return nil, err
+		}
+		data = data[len(encryptedMagic):]
+		if len(data) < aead.NonceSize() {
+			return nil, ErrWrongKey
+		}
+		nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if data, err = aead.Open(nil, nonce, ciphertext, encryptedMagic); err != nil {
+			return nil, ErrWrongKey
+		}
+	}
+
+	var err error
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		var gzipReader *gzip.Reader
+		if gzipReader, err = gzip.NewReader(bytes.NewReader(data)); err == nil {
+			data, err = io.ReadAll(gzipReader)
+		}
+	case bytes.HasPrefix(data, zstdMagic):
+		var zstdDecoder *zstd.Decoder
+		if zstdDecoder, err = zstd.NewReader(nil); err == nil {
+			data, err = zstdDecoder.DecodeAll(data, nil)
+			zstdDecoder.Close()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("corrupted compressed state file: %w", err)
+	}
+	return data, nil
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,119 @@
+package state_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/ipastusi/netreact/state"
+)
+
+var (
+	key      = bytes.Repeat([]byte{0x01}, state.KeySize)
+	wrongKey = bytes.Repeat([]byte{0x02}, state.KeySize)
+)
+
+func Test_EncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	stateBytes := []byte(`{"version":4,"items":[{"ip":"10.0.0.1","mac":"00:00:00:01:02:03","firstTs":1,"lastTs":1,"count":1}]}`)
+	data := map[string]struct {
+		fileName string
+		key      []byte
+		magic    []byte
+	}{
+		"plain":           {"nrstate.json", nil, []byte("{")},
+		"gzip":            {"nrstate.json.gz", nil, []byte{0x1f, 0x8b}},
+		"zstd":            {"nrstate.json.zst", nil, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+		"encrypted":       {"nrstate.json", key, []byte("NRENC1")},
+		"gzip, encrypted": {"nrstate.json.gz", key, []byte("NRENC1")},
+		"zstd, encrypted": {"nrstate.json.zst", key, []byte("NRENC1")},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			encoded, err := state.Encode(d.fileName, stateBytes, d.key)
+			if err != nil {
+				t.Fatal("unexpected error encoding:", err)
+			}
+			if !bytes.HasPrefix(encoded, d.magic) {
+				t.Fatalf("unexpected encoded data: %x", encoded)
+			}
+			decoded, err := state.Decode(encoded, d.key)
+			if err != nil {
+				t.Fatal("unexpected error decoding:", err)
+			}
+			if !bytes.Equal(decoded, stateBytes) {
+				t.Fatalf("unexpected decoded data, expected: %s, got: %s", stateBytes, decoded)
+			}
+		})
+	}
+}
+
+func Test_DecodeErrors(t *testing.T) {
+	t.Parallel()
+
+	encrypted, err := state.Encode("nrstate.json.gz", []byte(`{"items":[]}`), key)
+	if err != nil {
+		t.Fatal("unexpected error encoding:", err)
+	}
+	tampered := bytes.Clone(encrypted)
+	tampered[len(tampered)-1] ^= 0xff
+	compressed, err := state.Encode("nrstate.json.gz", []byte(`{"items":[]}`), nil)
+	if err != nil {
+		t.Fatal("unexpected error encoding:", err)
+	}
+
+	data := map[string]struct {
+		data        []byte
+		key         []byte
+		expectedErr error
+	}{
+		"no key":           {encrypted, nil, state.ErrEncrypted},
+		"wrong key":        {encrypted, wrongKey, state.ErrWrongKey},
+		"tampered":         {tampered, key, state.ErrWrongKey},
+		"truncated":        {encrypted[:8], key, state.ErrWrongKey},
+		"corrupted gzip":   {compressed[:len(compressed)-4], nil, nil},
+		"corrupted zstd":   {[]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, nil, nil},
+		"invalid key size": {encrypted, key[:10], nil},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := state.Decode(d.data, d.key)
+			if err == nil || (d.expectedErr != nil && !errors.Is(err, d.expectedErr)) {
+				t.Fatalf("unexpected error, expected: %v, got: %v", d.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_ParseKey(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]struct {
+		text  string
+		valid bool
+	}{
+		"hex":        {hex.EncodeToString(key) + "\n", true},
+		"base64":     {"AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=", true},
+		"too short":  {"0101", false},
+		"not a key":  {"correct horse battery staple", false},
+		"empty file": {"", false},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			parsed, err := state.ParseKey(d.text)
+			if d.valid && (err != nil || !bytes.Equal(parsed, key)) {
+				t.Fatalf("unexpected key: %x, error: %v", parsed, err)
+			} else if !d.valid && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package state
+
+import (
+	"bytes"
+	"cmp"
+	"maps"
+	"net"
+	"slices"
+)
+
+// Rebinding is an IP address bound to different MACs in two states
+type Rebinding struct {
+	Ip      string
+	OldMacs []string
+	NewMacs []string
+}
+
+type Diff struct {
+	// hosts with IP addresses not present in the old state
+	Added []Item
+	// hosts with IP addresses no longer present in the new state
+	Removed []Item
+	// IP addresses present in both states, but bound to different MACs
+	Rebound []Rebinding
+}
+
+// Compare lists the hosts added, removed or rebound between the old and the new state, sorted by IP address
+func Compare(oldState AppState, newState AppState) Diff {
+	oldIps, newIps := ipToItems(oldState), ipToItems(newState)
+
+	var diff Diff
+	for ip, items := range newIps {
+		if _, ok := oldIps[ip]; !ok {
+			diff.Added = append(diff.Added, items...)
+		}
+	}
+	for ip, oldItems := range oldIps {
+		newItems, ok := newIps[ip]
+		if !ok {
+			diff.Removed = append(diff.Removed, oldItems...)
+			continue
+		}
+		oldMacs, newMacs := macs(oldItems), macs(newItems)
+		if !slices.Equal(oldMacs, newMacs) {
+			diff.Rebound = append(diff.Rebound, Rebinding{Ip: ip, OldMacs: oldMacs, NewMacs: newMacs})
+		}
+	}
+
+	byIpMac := func(a, b Item) int {
+		return cmp.Or(CompareIps(a.Ip, b.Ip), cmp.Compare(a.Mac, b.Mac))
+	}
+	slices.SortFunc(diff.Added, byIpMac)
+	slices.SortFunc(diff.Removed, byIpMac)
+	slices.SortFunc(diff.Rebound, func(a, b Rebinding) int {
+		return CompareIps(a.Ip, b.Ip)
+	})
+	return diff
+}
+
+// CompareIps orders the IP addresses numerically rather than lexically, e.g. 10.0.0.2 before 10.0.0.10
+func CompareIps(a string, b string) int {
+	return bytes.Compare(net.ParseIP(a).To16(), net.ParseIP(b).To16())
+}
+
+func ipToItems(appState AppState) map[string][]Item {
+	items := map[string][]Item{}
+	for _, item := range appState.Items {
+		items[item.Ip] = append(items[item.Ip], item)
+	}
+	return items
+}
+
+// macs returns the sorted, unique MACs of the items
+func macs(items []Item) []string {
+	unique := map[string]struct{}{}
+	for _, item := range items {
+		unique[item.Mac] = struct{}{}
+	}
+	return slices.Sorted(maps.Keys(unique))
+}
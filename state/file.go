@@ -0,0 +1,112 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrBackup is returned by WriteFile if the state file was written, but the backup of the previous one failed
+var ErrBackup = errors.New("state file saved, but backup failed")
+
+// WriteFile atomically replaces the state file, so that it's never left truncated, e.g. after a crash or power loss.
+// The data is written to a temporary file in the same directory, synced and renamed over the state file. If backup is
+// true, the previous state file is kept with a .bak suffix. A failed backup doesn't prevent the state file from being
+// written, it's reported as ErrBackup afterwards.
+func WriteFile(fileName string, data []byte, backup bool) error {
+	dir, base := filepath.Split(fileName)
+	tmpFile, err := os.CreateTemp(dir, base+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpFileName := tmpFile.Name()
+	defer func() {
+		// no-op once renamed
+		_ = os.Remove(tmpFileName)
+	}()
+
+	_, err = tmpFile.Write(data)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if err1 := tmpFile.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(tmpFileName, 0644); err != nil {
+		return err
+	}
+
+	var backupErr error
+	if backup {
+		if err = backupFile(fileName); err != nil {
+			backupErr = fmt.Errorf("%w: %w", ErrBackup, err)
+		}
+	}
+	if err = os.Rename(tmpFileName, fileName); err != nil {
+		return err
+	}
+	if err = syncDir(dir); err != nil {
+		return err
+	}
+	return backupErr
+}
+
+func BackupFileName(fileName string) string {
+	return fileName + ".bak"
+}
+
+// backupFile hard links the current state file as the backup, so that the state file itself is in place all the time.
+// The state file is copied instead on filesystems without hard links, e.g. FAT or SMB shares.
+func backupFile(fileName string) error {
+	backupFileName := BackupFileName(fileName)
+	if err := os.Remove(backupFileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err := os.Link(fileName, backupFileName)
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return copyFile(fileName, backupFileName)
+}
+
+func copyFile(srcFileName string, dstFileName string) error {
+	src, err := os.Open(srcFileName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.OpenFile(dstFileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if err1 := dst.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	return err
+}
+
+// syncDir makes sure the rename itself is persisted
+func syncDir(dir string) error {
+	if dir == "" {
+		dir = "."
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if err1 := d.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	return err
+}
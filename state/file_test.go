@@ -0,0 +1,89 @@
+package state_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipastusi/netreact/state"
+)
+
+func Test_WriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "nrstate.json")
+	backupFileName := state.BackupFileName(fileName)
+
+	data := []struct {
+		content        string
+		backup         bool
+		expectedBackup string
+	}{
+		{`{"items":[]}`, true, ""},
+		{`{"items":[{"ip":"10.0.0.1"}]}`, true, `{"items":[]}`},
+		{`{"items":[{"ip":"10.0.0.2"}]}`, true, `{"items":[{"ip":"10.0.0.1"}]}`},
+		{`{"items":[{"ip":"10.0.0.3"}]}`, false, `{"items":[{"ip":"10.0.0.1"}]}`},
+	}
+
+	for i, d := range data {
+		if err := state.WriteFile(fileName, []byte(d.content), d.backup); err != nil {
+			t.Fatalf("unexpected error in iteration %v: %v", i, err)
+		}
+
+		content, err := os.ReadFile(fileName)
+		if err != nil || string(content) != d.content {
+			t.Fatalf("unexpected state file content in iteration %v, expected: %v, got: %v, error: %v", i, d.content, string(content), err)
+		}
+
+		backupContent, err := os.ReadFile(backupFileName)
+		if d.expectedBackup == "" {
+			if err == nil {
+				t.Fatalf("unexpected backup file in iteration %v", i)
+			}
+		} else if err != nil || string(backupContent) != d.expectedBackup {
+			t.Fatalf("unexpected backup file content in iteration %v, expected: %v, got: %v, error: %v", i, d.expectedBackup, string(backupContent), err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal("error listing directory:", err)
+	}
+	if len(entries) != 2 {
+		t.Fatal("unexpected files left behind:", entries)
+	}
+}
+
+func Test_WriteFileNonexistentDir(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "nonexistent", "nrstate.json")
+	if err := state.WriteFile(fileName, []byte(`{"items":[]}`), true); err == nil {
+		t.Fatal("no error writing to nonexistent directory")
+	}
+}
+
+func Test_WriteFileBackupFailed(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "nrstate.json")
+	if err := state.WriteFile(fileName, []byte(`{"items":[]}`), true); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	// a non-empty directory in place of the backup file can't be removed
+	backupFileName := state.BackupFileName(fileName)
+	if err := os.MkdirAll(filepath.Join(backupFileName, "dir"), 0755); err != nil {
+		t.Fatal("error creating directory:", err)
+	}
+
+	content := `{"items":[{"ip":"10.0.0.1"}]}`
+	if err := state.WriteFile(fileName, []byte(content), true); !errors.Is(err, state.ErrBackup) {
+		t.Fatal("expected backup error, got:", err)
+	}
+	if actual, err := os.ReadFile(fileName); err != nil || string(actual) != content {
+		t.Fatalf("state file not written, got: %v, error: %v", string(actual), err)
+	}
+}
@@ -0,0 +1,117 @@
+package state
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Merge combines the states, e.g. collected by several sensors, into a single state. Hosts are matched by their IP-MAC
+// pair: the earliest first seen and the latest last seen timestamps are kept, packet and ARP counters are summed up, and
+// the remaining host details are taken from the most recently seen copy. Presence statistics are not recalculated.
+func Merge(appStates ...AppState) AppState {
+	merged := NewAppState()
+	index := map[string]int{}
+	vendors := map[string]struct{}{}
+	for _, appState := range appStates {
+		for _, item := range appState.Items {
+			key := item.Ip + "," + item.Mac
+			if i, ok := index[key]; ok {
+				merged.Items[i] = mergeItems(merged.Items[i], item)
+			} else {
+				index[key] = len(merged.Items)
+				merged.Items = append(merged.Items, cloneItem(item))
+			}
+		}
+		for _, vendor := range appState.Vendors {
+			vendors[vendor] = struct{}{}
+		}
+	}
+
+	slices.SortStableFunc(merged.Items, func(a, b Item) int {
+		return cmp.Compare(a.FirstTs, b.FirstTs)
+	})
+	for vendor := range vendors {
+		merged.Vendors = append(merged.Vendors, vendor)
+	}
+	slices.Sort(merged.Vendors)
+	return merged
+}
+
+func mergeItems(a Item, b Item) Item {
+	latest, other := a, b
+	if b.LastTs > a.LastTs {
+		latest, other = b, a
+	}
+
+	merged := cloneItem(latest)
+	merged.FirstTs = min(a.FirstTs, b.FirstTs)
+	merged.Count = a.Count + b.Count
+	merged.Requests = a.Requests + b.Requests
+	merged.Replies = a.Replies + b.Replies
+	merged.Probes = a.Probes + b.Probes
+	merged.Announcements = a.Announcements + b.Announcements
+	if merged.Vendor == "" {
+		merged.Vendor = other.Vendor
+	}
+	if merged.Notes == "" {
+		merged.Notes = other.Notes
+	}
+	merged.Labels = union(merged.Labels, other.Labels)
+	merged.PreviousIps = union(other.PreviousIps, merged.PreviousIps)
+	merged.Transitions = mergeTransitions(merged.Transitions, other.Transitions)
+	merged.Presence = mergeIntervals(merged.Presence, other.Presence)
+	return merged
+}
+
+func cloneItem(item Item) Item {
+	item.Labels = slices.Clone(item.Labels)
+	item.Transitions = slices.Clone(item.Transitions)
+	item.PreviousIps = slices.Clone(item.PreviousIps)
+	item.Presence = slices.Clone(item.Presence)
+	item.ActiveHours = slices.Clone(item.ActiveHours)
+	return item
+}
+
+// union appends the values of b missing from a, keeping the order
+func union(a []string, b []string) []string {
+	for _, value := range b {
+		if !slices.Contains(a, value) {
+			a = append(a, value)
+		}
+	}
+	return a
+}
+
+// mergeTransitions sorts the transitions of both hosts by time, dropping the ones not changing the online status
+func mergeTransitions(a []Transition, b []Transition) []Transition {
+	all := append(slices.Clone(a), b...)
+	slices.SortStableFunc(all, func(x, y Transition) int {
+		return cmp.Compare(x.Ts, y.Ts)
+	})
+
+	var merged []Transition
+	for _, transition := range all {
+		if len(merged) == 0 || merged[len(merged)-1].Online != transition.Online {
+			merged = append(merged, transition)
+		}
+	}
+	return merged
+}
+
+// mergeIntervals sorts the intervals of both hosts by time, joining the overlapping ones
+func mergeIntervals(a []Interval, b []Interval) []Interval {
+	all := append(slices.Clone(a), b...)
+	slices.SortFunc(all, func(x, y Interval) int {
+		return cmp.Compare(x.Start, y.Start)
+	})
+
+	var merged []Interval
+	for _, interval := range all {
+		if n := len(merged); n > 0 && interval.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, interval.End)
+		} else {
+			merged = append(merged, interval)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,83 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ipastusi/netreact/state"
+)
+
+func Test_Merge(t *testing.T) {
+	t.Parallel()
+
+	sensor1 := state.NewAppState()
+	sensor1.Items = []state.Item{
+		{
+			Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1000, LastTs: 5000, Count: 3, Vendor: "XEROX CORPORATION",
+			Labels: []string{"printer"}, Interface: "eth0", Requests: 3,
+			Transitions: []state.Transition{{Ts: 1000, Online: true}},
+			Presence:    []state.Interval{{Start: 1000, End: 5000}},
+		},
+		{Ip: "10.0.0.2", Mac: "00:00:00:04:05:06", FirstTs: 2000, LastTs: 2000, Count: 1},
+	}
+	sensor1.Vendors = []string{"XEROX CORPORATION"}
+
+	sensor2 := state.NewAppState()
+	sensor2.Items = []state.Item{
+		{
+			Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 500, LastTs: 9000, Count: 2, Vendor: "XEROX CORPORATION",
+			Labels: []string{"office", "printer"}, Interface: "eth1", Requests: 1, Replies: 1, Notes: "2nd floor",
+			Transitions: []state.Transition{{Ts: 500, Online: true}, {Ts: 6000, Online: false}, {Ts: 8000, Online: true}},
+			Presence:    []state.Interval{{Start: 500, End: 2000}, {Start: 8000, End: 9000}},
+		},
+	}
+	sensor2.Vendors = []string{"Apple, Inc."}
+
+	merged := state.Merge(sensor1, sensor2)
+	expected := state.NewAppState()
+	expected.Items = []state.Item{
+		{
+			Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 500, LastTs: 9000, Count: 5, Vendor: "XEROX CORPORATION",
+			Labels: []string{"office", "printer"}, Interface: "eth1", Requests: 4, Replies: 1, Notes: "2nd floor",
+			Transitions: []state.Transition{{Ts: 500, Online: true}, {Ts: 6000, Online: false}, {Ts: 8000, Online: true}},
+			Presence:    []state.Interval{{Start: 500, End: 5000}, {Start: 8000, End: 9000}},
+		},
+		{Ip: "10.0.0.2", Mac: "00:00:00:04:05:06", FirstTs: 2000, LastTs: 2000, Count: 1},
+	}
+	expected.Vendors = []string{"Apple, Inc.", "XEROX CORPORATION"}
+	if diff := cmp.Diff(expected, merged); diff != "" {
+		t.Fatal("unexpected merged state:", diff)
+	}
+
+	// inputs left intact
+	if sensor1.Items[0].Count != 3 || len(sensor1.Items[0].Labels) != 1 {
+		t.Fatal("unexpected change of merged state:", sensor1.Items[0])
+	}
+}
+
+func Test_Compare(t *testing.T) {
+	t.Parallel()
+
+	oldState := state.NewAppState()
+	oldState.Items = []state.Item{
+		{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03"},
+		{Ip: "10.0.0.2", Mac: "00:00:00:04:05:06"},
+		{Ip: "10.0.0.10", Mac: "00:00:00:07:08:09"},
+	}
+	newState := state.NewAppState()
+	newState.Items = []state.Item{
+		{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03"},
+		{Ip: "10.0.0.10", Mac: "00:00:00:0a:0b:0c"},
+		{Ip: "10.0.0.3", Mac: "00:00:00:04:05:06"},
+	}
+
+	diff := state.Compare(oldState, newState)
+	expected := state.Diff{
+		Added:   []state.Item{{Ip: "10.0.0.3", Mac: "00:00:00:04:05:06"}},
+		Removed: []state.Item{{Ip: "10.0.0.2", Mac: "00:00:00:04:05:06"}},
+		Rebound: []state.Rebinding{{Ip: "10.0.0.10", OldMacs: []string{"00:00:00:07:08:09"}, NewMacs: []string{"00:00:00:0a:0b:0c"}}},
+	}
+	if d := cmp.Diff(expected, diff); d != "" {
+		t.Fatal("unexpected diff:", d)
+	}
+}
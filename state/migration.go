@@ -0,0 +1,96 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// CurrentVersion is the version of the state documents written by this binary. State documents without the version
+// field are version 1.
+const CurrentVersion = 5
+
+var ErrNewerVersion = errors.New("state file created by a newer version of netreact")
+
+// migrations upgrade a state document from the version they're indexed with to the next one
+var migrations = map[int]func(doc map[string]any) error{
+	1: migrateV1,
+	2: migrateV2,
+	3: migrateV3,
+	4: migrateV4,
+}
+
+// Load validates the state document against the schema matching its version, upgrades it to the current version if
+// needed and deserializes it
+func Load(stateBytes []byte) (AppState, error) {
+	if errs := ValidateState(stateBytes); len(errs) > 0 {
+		return AppState{}, errors.Join(errs...)
+	}
+
+	version, _ := documentVersion(stateBytes)
+	if version < CurrentVersion {
+		var err error
+		stateBytes, err = migrate(stateBytes, version)
+		if err != nil {
+			return AppState{}, err
+		}
+	}
+	return FromJson(stateBytes)
+}
+
+func migrate(stateBytes []byte, version int) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(stateBytes, &doc); err != nil {
+		return nil, err
+	}
+
+	for ; version < CurrentVersion; version++ {
+		if err := migrations[version](doc); err != nil {
+			return nil, fmt.Errorf("error migrating state from version %v: %w", version, err)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// documentVersion reads the version of the state document, without validating the rest of it
+func documentVersion(stateBytes []byte) (int, error) {
+	var doc struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(stateBytes, &doc); err != nil {
+		return 0, err
+	}
+
+	if doc.Version == nil {
+		return 1, nil
+	} else if *doc.Version > CurrentVersion {
+		return 0, fmt.Errorf("%w: state file version %v, latest supported version %v", ErrNewerVersion, *doc.Version, CurrentVersion)
+	} else if *doc.Version < 1 {
+		return 0, fmt.Errorf("invalid state file version: %v", *doc.Version)
+	}
+	return *doc.Version, nil
+}
+
+// migrateV1 adds the version field. The MAC type fields and the vendors introduced in version 2 are optional.
+func migrateV1(doc map[string]any) error {
+	doc["version"] = 2
+	return nil
+}
+
+// migrateV2 only bumps the version, as all the new host fields are optional
+func migrateV2(doc map[string]any) error {
+	doc["version"] = 3
+	return nil
+}
+
+// migrateV3 only bumps the version, as the presence fields are optional
+func migrateV3(doc map[string]any) error {
+	doc["version"] = 4
+	return nil
+}
+
+// migrateV4 only bumps the version, as states without the journal sequence number were saved without a journal
+func migrateV4(doc map[string]any) error {
+	doc["version"] = 5
+	return nil
+}
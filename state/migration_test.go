@@ -0,0 +1,90 @@
+package state_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ipastusi/netreact/state"
+)
+
+func Test_LoadV1(t *testing.T) {
+	t.Parallel()
+
+	stateBytes := []byte(`{
+		"items": [
+			{
+				"ip": "10.0.0.1",
+				"mac": "00:00:00:01:02:03",
+				"firstTs": 1749913040850,
+				"lastTs": 1749913040851,
+				"count": 2
+			}
+		]}`)
+	appState, err := state.Load(stateBytes)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expectedItems := []state.Item{
+		{
+			Ip:      "10.0.0.1",
+			Mac:     "00:00:00:01:02:03",
+			FirstTs: 1749913040850,
+			LastTs:  1749913040851,
+			Count:   2,
+		},
+	}
+	if appState.Version != state.CurrentVersion || cmp.Diff(appState.Items, expectedItems) != "" {
+		t.Fatalf("unexpected app state, expected version %v and items %v, got: %v", state.CurrentVersion, expectedItems, appState)
+	}
+}
+
+func Test_LoadCurrent(t *testing.T) {
+	t.Parallel()
+
+	appState := state.NewAppState()
+	appState.Items = []state.Item{{Ip: "10.0.0.1", Mac: "00:00:00:01:02:03", FirstTs: 1749913040850, LastTs: 1749913040850, Count: 1}}
+	appState.Vendors = []string{"XEROX CORPORATION"}
+	stateBytes, err := appState.ToJson()
+	if err != nil {
+		t.Fatal("error serializing input:", err)
+	}
+
+	loadedAppState, err := state.Load(stateBytes)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if loadedAppState.Version != appState.Version || cmp.Diff(loadedAppState, appState) != "" {
+		t.Fatalf("unexpected app state, expected: %v, got: %v", appState, loadedAppState)
+	}
+}
+
+func Test_LoadError(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]struct {
+		state       string
+		expectedErr error
+	}{
+		"newer version":      {`{"version": 999, "items": []}`, state.ErrNewerVersion},
+		"invalid version":    {`{"version": 0, "items": []}`, nil},
+		"invalid v1":         {`{"items": [{"ip": "10.0.0.1"}]}`, nil},
+		"invalid v2":         {`{"version": 2, "items": [{"ip": "10.0.0.1"}]}`, nil},
+		"unknown v1 field":   {`{"items": [], "other": true}`, nil},
+		"corrupted document": {`{"version": 2, "items": [`, nil},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := state.Load([]byte(d.state))
+			if err == nil {
+				t.Fatal("no error loading invalid state")
+			}
+			if d.expectedErr != nil && !errors.Is(err, d.expectedErr) {
+				t.Fatalf("unexpected error, expected: %v, got: %v", d.expectedErr, err)
+			}
+		})
+	}
+}
@@ -5,19 +5,53 @@ import (
 )
 
 type AppState struct {
-	Items []Item `json:"items"`
+	Version int    `json:"version"`
+	Items   []Item `json:"items"`
+	// MAC vendors seen so far, kept even if all their hosts are gone
+	Vendors []string `json:"vendors,omitempty"`
+	// sequence number of the last journal record included in this state
+	JournalSeq uint64 `json:"journalSeq,omitempty"`
 }
 
 type Item struct {
-	Ip      string `json:"ip"`
-	Mac     string `json:"mac"`
-	FirstTs int64  `json:"firstTs"`
-	LastTs  int64  `json:"lastTs"`
-	Count   int    `json:"count"`
+	Ip                  string `json:"ip"`
+	Mac                 string `json:"mac"`
+	FirstTs             int64  `json:"firstTs"`
+	LastTs              int64  `json:"lastTs"`
+	Count               int    `json:"count"`
+	LocallyAdministered bool   `json:"locallyAdministered,omitempty"`
+	Multicast           bool   `json:"multicast,omitempty"`
+	Vendor              string `json:"vendor,omitempty"`
+	// labels and notes are not set by netreact, but can be added to the state file and are kept
+	Labels        []string     `json:"labels,omitempty"`
+	Notes         string       `json:"notes,omitempty"`
+	Interface     string       `json:"interface,omitempty"`
+	Vlan          uint16       `json:"vlan,omitempty"`
+	Requests      int          `json:"requests,omitempty"`
+	Replies       int          `json:"replies,omitempty"`
+	Probes        int          `json:"probes,omitempty"`
+	Announcements int          `json:"announcements,omitempty"`
+	Transitions   []Transition `json:"transitions,omitempty"`
+	PreviousIps   []string     `json:"previousIps,omitempty"`
+	Presence      []Interval   `json:"presence,omitempty"`
+	// presence statistics, calculated when saving the state
+	UptimePct   float64 `json:"uptimePct,omitempty"`
+	ActiveHours []int   `json:"activeHours,omitempty"`
+}
+
+type Interval struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+type Transition struct {
+	Ts     int64 `json:"ts"`
+	Online bool  `json:"online"`
 }
 
 func NewAppState() AppState {
 	return AppState{
+		Version: CurrentVersion,
 		// nil vs empty slice matters when marshalling to json
 		Items: make([]Item, 0),
 	}
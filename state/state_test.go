@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/ipastusi/netreact/state"
 )
 
@@ -37,7 +38,7 @@ func Test_FromJson(t *testing.T) {
 		LastTs:  1749913040851,
 		Count:   2,
 	}
-	if appState.Items[0] != expectedItem {
+	if cmp.Diff(appState.Items[0], expectedItem) != "" {
 		t.Fatalf("incorrect deserialisation, expected: %v, actual: %v", expectedItem, appState.Items[0])
 	}
 }
@@ -68,7 +69,7 @@ func Test_ToJson(t *testing.T) {
 		t.Fatal("error serializing input:", err)
 	}
 
-	expectedOutputJson := `{"items":[{"ip":"10.0.0.1","mac":"00:00:00:01:02:03","firstTs":1749913040850,"lastTs":1749913040851,"count":2},{"ip":"10.0.0.2","mac":"00:00:00:04:05:06","firstTs":1749913040852,"lastTs":1749913040852,"count":1}]}`
+	expectedOutputJson := `{"version":5,"items":[{"ip":"10.0.0.1","mac":"00:00:00:01:02:03","firstTs":1749913040850,"lastTs":1749913040851,"count":2},{"ip":"10.0.0.2","mac":"00:00:00:04:05:06","firstTs":1749913040852,"lastTs":1749913040852,"count":1}]}`
 	if actualOutputJson != expectedOutputJson {
 		t.Fatalf("incorrect output json, expected: \n%v\nactual: \n%v", expectedOutputJson, actualOutputJson)
 	}
@@ -77,7 +78,7 @@ func Test_ToJson(t *testing.T) {
 func Test_FromJsonToJson(t *testing.T) {
 	t.Parallel()
 
-	jsonInput := []byte(`{"items":[{"ip":"10.0.0.1","mac":"00:00:00:01:02:03","firstTs":1749913040850,"lastTs":1749913040851,"count":2},{"ip":"10.0.0.2","mac":"00:00:00:04:05:06","firstTs":1749913040852,"lastTs":1749913040852,"count":1}]}`)
+	jsonInput := []byte(`{"version":5,"items":[{"ip":"10.0.0.1","mac":"00:00:00:01:02:03","firstTs":1749913040850,"lastTs":1749913040851,"count":2},{"ip":"10.0.0.2","mac":"00:00:00:04:05:06","firstTs":1749913040852,"lastTs":1749913040852,"count":1}]}`)
 	appState, err := state.FromJson(jsonInput)
 	if err != nil {
 		t.Fatal("error during deserialization")
@@ -98,7 +99,7 @@ func Test_ToJsonEmpty(t *testing.T) {
 
 	appState := state.NewAppState()
 	outputJson, _ := appState.ToJson()
-	if !bytes.Equal(outputJson, []byte(`{"items":[]}`)) {
+	if !bytes.Equal(outputJson, []byte(`{"version":5,"items":[]}`)) {
 		t.Fatal("unexpected outputJson:", string(outputJson))
 	}
 }
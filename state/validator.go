@@ -1,15 +1,27 @@
 package state
 
 import (
-	_ "embed"
+	"embed"
+	"fmt"
 
 	"github.com/kaptinlin/jsonschema"
 )
 
-//go:embed schema.json
-var rawSchema []byte
+//go:embed schemas/*.json
+var schemas embed.FS
 
+// ValidateState validates the state document against the schema matching its version
 func ValidateState(stateBytes []byte) []error {
+	version, err := documentVersion(stateBytes)
+	if err != nil {
+		return []error{err}
+	}
+
+	rawSchema, err := schemas.ReadFile(fmt.Sprintf("schemas/v%v.json", version))
+	if err != nil {
+		return []error{err}
+	}
+
 	compiler := jsonschema.NewCompiler()
 	schema, err := compiler.Compile(rawSchema)
 	if err != nil {
@@ -27,6 +27,83 @@ func Test_ValidateState(t *testing.T) {
 	}
 }
 
+func Test_ValidateStateVendors(t *testing.T) {
+	t.Parallel()
+
+	stateBytes := []byte(`{
+		"version": 2,
+		"items": [
+    		{
+				"ip": "192.168.0.1",
+    			"mac": "b4:b6:86:01:02:03",
+        		"firstTs": 1751972610000,
+        		"lastTs": 1751972610000,
+        		"count": 10
+			}
+    ],
+		"vendors": ["Apple, Inc.", "Hewlett Packard"]}`)
+
+	errs := state.ValidateState(stateBytes)
+	if len(errs) > 0 {
+		t.Fatal("unexpected validation error:", errs)
+	}
+}
+
+func Test_ValidateStateMacType(t *testing.T) {
+	t.Parallel()
+
+	stateBytes := []byte(`{
+		"version": 2,
+		"items": [
+    		{
+				"ip": "192.168.0.1",
+    			"mac": "da:a1:19:01:02:03",
+        		"firstTs": 1751972610000,
+        		"lastTs": 1751972610000,
+        		"count": 10,
+        		"locallyAdministered": true,
+        		"multicast": false
+			}
+    ]}`)
+
+	errs := state.ValidateState(stateBytes)
+	if len(errs) > 0 {
+		t.Fatal("unexpected validation error:", errs)
+	}
+}
+
+func Test_ValidateStateVersions(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]struct {
+		state string
+		valid bool
+	}{
+		"valid":            {`{"version": 2, "items": [], "vendors": ["Apple, Inc."]}`, true},
+		"unversioned":      {`{"items": []}`, true},
+		"unknown v1 field": {`{"items": [], "vendors": ["Apple, Inc."]}`, false},
+		"newer version":    {`{"version": 99, "items": []}`, false},
+		"unknown v2 field": {`{"version": 2, "items": [{"ip": "10.0.0.1", "mac": "00:00:00:01:02:03", "firstTs": 1, "lastTs": 1, "count": 1, "vendor": "XEROX CORPORATION"}]}`, false},
+		"valid v3":         {`{"version": 3, "items": [{"ip": "10.0.0.1", "mac": "00:00:00:01:02:03", "firstTs": 1, "lastTs": 1, "count": 1, "vendor": "XEROX CORPORATION", "vlan": 10, "transitions": [{"ts": 1, "online": true}]}]}`, true},
+		"invalid v3 VLAN":  {`{"version": 3, "items": [{"ip": "10.0.0.1", "mac": "00:00:00:01:02:03", "firstTs": 1, "lastTs": 1, "count": 1, "vlan": 4096}]}`, false},
+		"valid v4":         {`{"version": 4, "items": [{"ip": "10.0.0.1", "mac": "00:00:00:01:02:03", "firstTs": 1, "lastTs": 1, "count": 1, "presence": [{"start": 1, "end": 1}], "uptimePct": 12.5, "activeHours": [8, 9]}]}`, true},
+		"invalid v4 hour":  {`{"version": 4, "items": [{"ip": "10.0.0.1", "mac": "00:00:00:01:02:03", "firstTs": 1, "lastTs": 1, "count": 1, "activeHours": [24]}]}`, false},
+		"valid v5":         {`{"version": 5, "items": [], "journalSeq": 42}`, true},
+		"unknown v4 field": {`{"version": 4, "items": [], "journalSeq": 42}`, false},
+		"missing items":    {`{"version": 2}`, false},
+	}
+
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			errs := state.ValidateState([]byte(d.state))
+			if (len(errs) == 0) != d.valid {
+				t.Fatalf("unexpected validation result, expected valid: %v, got: %v", d.valid, errs)
+			}
+		})
+	}
+}
+
 func Test_ValidateStateEmpty(t *testing.T) {
 	t.Parallel()
 
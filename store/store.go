@@ -0,0 +1,371 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipastusi/netreact/event"
+	"github.com/ipastusi/netreact/oui"
+	"github.com/ipastusi/netreact/state"
+	bolt "go.etcd.io/bbolt"
+	berrors "go.etcd.io/bbolt/errors"
+)
+
+// expiryIntervalMs is how often the sightings and events older than the retention period are deleted
+const expiryIntervalMs = 3600 * 1000
+
+var (
+	hostsBucket     = []byte("hosts")
+	sightingsBucket = []byte("sightings")
+	eventsBucket    = []byte("events")
+	vendorsBucket   = []byte("vendors")
+)
+
+// Store keeps hosts, their sightings and the generated events in an embedded database. Updates are buffered in memory
+// and written in a single transaction by Flush, as committing a transaction for every ARP packet would be too slow.
+type Store struct {
+	db                 *bolt.DB
+	sightingIntervalMs int64
+	retentionMs        int64
+	lastExpiryTs       int64
+	// guards the pending updates, as Flush is also called when shutting down
+	mu               sync.Mutex
+	pendingHosts     map[string]state.Item
+	pendingRemovals  map[string]struct{}
+	pendingSightings map[string][]int64
+	pendingEvents    []pendingEvent
+	lastSightings    map[string]int64
+}
+
+type pendingEvent struct {
+	ts   int64
+	data []byte
+}
+
+// Open opens or creates the database file. Host sightings are recorded at most once every sightingIntervalSec. Sightings
+// and events older than retentionDays are deleted, unless retentionDays is 0.
+func Open(fileName string, sightingIntervalSec uint, retentionDays uint) (*Store, error) {
+	db, err := bolt.Open(fileName, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening database %v: %w", fileName, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{hostsBucket, sightingsBucket, eventsBucket, vendorsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Store{
+		db:                 db,
+		sightingIntervalMs: int64(sightingIntervalSec) * 1000,
+		retentionMs:        int64(retentionDays) * 24 * 3600 * 1000,
+		pendingHosts:       map[string]state.Item{},
+		pendingRemovals:    map[string]struct{}{},
+		pendingSightings:   map[string][]int64{},
+		lastSightings:      map[string]int64{},
+	}, nil
+}
+
+// RecordHost queues the current host record, and a sighting of the host if none was recorded within the sighting interval
+func (s *Store) RecordHost(item state.Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := hostKey(item.Ip, item.Mac)
+	s.pendingHosts[key] = item
+	delete(s.pendingRemovals, key)
+	if lastSighting, ok := s.lastSightings[key]; !ok || item.LastTs-lastSighting >= s.sightingIntervalMs {
+		s.pendingSightings[key] = append(s.pendingSightings[key], item.LastTs)
+		s.lastSightings[key] = item.LastTs
+	}
+}
+
+// RemoveHost queues the removal of the host, including its sightings
+func (s *Store) RemoveHost(ip string, mac string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := hostKey(ip, mac)
+	s.pendingRemovals[key] = struct{}{}
+	delete(s.pendingHosts, key)
+	delete(s.pendingSightings, key)
+	delete(s.lastSightings, key)
+}
+
+// StoreEvent queues the notification, implementing event.Sink
+func (s *Store) StoreEvent(notification event.Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pendingEvents = append(s.pendingEvents, pendingEvent{ts: notification.Ts, data: data})
+	return nil
+}
+
+// Flush writes all the queued updates in a single transaction, deleting the expired sightings and events every
+// expiryIntervalMs
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UnixMilli()
+	expire := s.retentionMs > 0 && now-s.lastExpiryTs >= expiryIntervalMs
+	if len(s.pendingHosts) == 0 && len(s.pendingRemovals) == 0 && len(s.pendingEvents) == 0 && !expire {
+		return nil
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := removeHosts(tx, s.pendingRemovals); err != nil {
+			return err
+		}
+		if err := putHosts(tx, s.pendingHosts); err != nil {
+			return err
+		}
+		if err := putSightings(tx, s.pendingSightings); err != nil {
+			return err
+		}
+		if err := putEvents(tx, s.pendingEvents); err != nil {
+			return err
+		}
+		if expire {
+			return expireBefore(tx, now-s.retentionMs)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if expire {
+		s.lastExpiryTs = now
+	}
+
+	s.pendingHosts = map[string]state.Item{}
+	s.pendingRemovals = map[string]struct{}{}
+	s.pendingSightings = map[string][]int64{}
+	s.pendingEvents = nil
+	return nil
+}
+
+// Close flushes the queued updates and closes the database
+func (s *Store) Close() error {
+	err := s.Flush()
+	if err1 := s.db.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	return err
+}
+
+// Import writes all the hosts and vendors from the app state, e.g. when switching from the JSON state file
+func (s *Store) Import(appState state.AppState) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		hosts := map[string]state.Item{}
+		for _, item := range appState.Items {
+			hosts[hostKey(item.Ip, item.Mac)] = item
+		}
+		if err := putHosts(tx, hosts); err != nil {
+			return err
+		}
+
+		vendors := tx.Bucket(vendorsBucket)
+		for _, vendor := range appState.Vendors {
+			if err := vendors.Put([]byte(vendor), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LoadAppState reads all the hosts and vendors, in the same format as the JSON state file
+func (s *Store) LoadAppState() (state.AppState, error) {
+	appState := state.NewAppState()
+	err := s.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(hostsBucket).ForEach(func(_, v []byte) error {
+			var item state.Item
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			appState.Items = append(appState.Items, item)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(vendorsBucket).ForEach(func(k, _ []byte) error {
+			appState.Vendors = append(appState.Vendors, string(k))
+			return nil
+		})
+	})
+	return appState, err
+}
+
+// Sightings lists the recorded sightings of the host between fromTs and toTs, inclusive
+func (s *Store) Sightings(ip string, mac string, fromTs int64, toTs int64) ([]int64, error) {
+	var sightings []int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		hostSightings := tx.Bucket(sightingsBucket).Bucket([]byte(hostKey(ip, mac)))
+		if hostSightings == nil {
+			return nil
+		}
+
+		c := hostSightings.Cursor()
+		for k, _ := c.Seek(tsKey(fromTs)); k != nil; k, _ = c.Next() {
+			ts := int64(binary.BigEndian.Uint64(k))
+			if ts > toTs {
+				break
+			}
+			sightings = append(sightings, ts)
+		}
+		return nil
+	})
+	return sightings, err
+}
+
+// Events lists the stored notifications between fromTs and toTs, inclusive, oldest first
+func (s *Store) Events(fromTs int64, toTs int64) ([]event.Notification, error) {
+	var notifications []event.Notification
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(eventsBucket).Cursor()
+		for k, v := c.Seek(tsKey(fromTs)); k != nil; k, v = c.Next() {
+			if int64(binary.BigEndian.Uint64(k[:8])) > toTs {
+				break
+			}
+			var notification event.Notification
+			if err := json.Unmarshal(v, &notification); err != nil {
+				return err
+			}
+			notifications = append(notifications, notification)
+		}
+		return nil
+	})
+	return notifications, err
+}
+
+func putHosts(tx *bolt.Tx, hosts map[string]state.Item) error {
+	hostsB, vendorsB := tx.Bucket(hostsBucket), tx.Bucket(vendorsBucket)
+	for key, item := range hosts {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if err = hostsB.Put([]byte(key), data); err != nil {
+			return err
+		}
+		if item.Vendor != "" && item.Vendor != oui.UnknownVendor {
+			if err = vendorsB.Put([]byte(item.Vendor), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func removeHosts(tx *bolt.Tx, keys map[string]struct{}) error {
+	hostsB, sightingsB := tx.Bucket(hostsBucket), tx.Bucket(sightingsBucket)
+	for key := range keys {
+		if err := hostsB.Delete([]byte(key)); err != nil {
+			return err
+		}
+		if err := sightingsB.DeleteBucket([]byte(key)); err != nil && !errors.Is(err, berrors.ErrBucketNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func putSightings(tx *bolt.Tx, sightings map[string][]int64) error {
+	sightingsB := tx.Bucket(sightingsBucket)
+	for key, timestamps := range sightings {
+		hostSightings, err := sightingsB.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+		for _, ts := range timestamps {
+			if err = hostSightings.Put(tsKey(ts), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func putEvents(tx *bolt.Tx, events []pendingEvent) error {
+	eventsB := tx.Bucket(eventsBucket)
+	for _, e := range events {
+		// the sequence keeps the events with the same timestamp apart
+		seq, err := eventsB.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := binary.BigEndian.AppendUint64(tsKey(e.ts), seq)
+		if err = eventsB.Put(key, e.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expireBefore deletes the sightings and events older than ts
+func expireBefore(tx *bolt.Tx, ts int64) error {
+	sightingsB := tx.Bucket(sightingsBucket)
+	var emptyHosts [][]byte
+	err := sightingsB.ForEachBucket(func(key []byte) error {
+		hostSightings := sightingsB.Bucket(key)
+		if err := deleteBefore(hostSightings, ts); err != nil {
+			return err
+		}
+		if k, _ := hostSightings.Cursor().First(); k == nil {
+			emptyHosts = append(emptyHosts, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range emptyHosts {
+		if err = sightingsB.DeleteBucket(key); err != nil {
+			return err
+		}
+	}
+	return deleteBefore(tx.Bucket(eventsBucket), ts)
+}
+
+// deleteBefore deletes the keys starting with a timestamp older than ts
+func deleteBefore(bucket *bolt.Bucket, ts int64) error {
+	// keys are collected first, as deleting while iterating makes the cursor skip keys
+	var keys [][]byte
+	c := bucket.Cursor()
+	for k, _ := c.First(); k != nil && int64(binary.BigEndian.Uint64(k[:8])) < ts; k, _ = c.Next() {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		if err := bucket.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hostKey(ip string, mac string) string {
+	return fmt.Sprintf("%v,%v", ip, mac)
+}
+
+// tsKey encodes the timestamp so that the keys sort chronologically
+func tsKey(ts int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(ts))
+}
@@ -0,0 +1,195 @@
+package store_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ipastusi/netreact/event"
+	"github.com/ipastusi/netreact/state"
+	"github.com/ipastusi/netreact/store"
+)
+
+func openStore(t *testing.T, fileName string) *store.Store {
+	db, err := store.Open(fileName, 60, 0)
+	if err != nil {
+		t.Fatal("error opening store:", err)
+	}
+	return db
+}
+
+func Test_StoreHosts(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "netreact.db")
+	db := openStore(t, fileName)
+
+	itemA := state.Item{Ip: "10.0.0.1", Mac: "b4:b6:86:01:02:03", FirstTs: 1749913040000, LastTs: 1749913040000, Count: 1, Vendor: "Hewlett Packard"}
+	itemB := state.Item{Ip: "10.0.0.2", Mac: "00:00:00:04:05:06", FirstTs: 1749913041000, LastTs: 1749913041000, Count: 1, Vendor: "XEROX CORPORATION"}
+	itemC := state.Item{Ip: "10.0.0.3", Mac: "da:a1:19:04:05:06", FirstTs: 1749913042000, LastTs: 1749913042000, Count: 1, Vendor: "Unknown"}
+	db.RecordHost(itemA)
+	db.RecordHost(itemB)
+	db.RecordHost(itemC)
+	if err := db.Flush(); err != nil {
+		t.Fatal("error flushing store:", err)
+	}
+
+	// updated and removed, not flushed until closed
+	itemA.LastTs, itemA.Count = 1749913043000, 2
+	db.RecordHost(itemA)
+	db.RemoveHost(itemB.Ip, itemB.Mac)
+	if err := db.Close(); err != nil {
+		t.Fatal("error closing store:", err)
+	}
+
+	db = openStore(t, fileName)
+	defer func() { _ = db.Close() }()
+	appState, err := db.LoadAppState()
+	if err != nil {
+		t.Fatal("error loading app state:", err)
+	}
+
+	expectedItems := []state.Item{itemA, itemC}
+	if diff := cmp.Diff(appState.Items, expectedItems); diff != "" {
+		t.Fatalf("unexpected hosts: %v", diff)
+	}
+	// vendors are kept, even if the hosts are gone
+	expectedVendors := []string{"Hewlett Packard", "XEROX CORPORATION"}
+	if !slices.Equal(appState.Vendors, expectedVendors) {
+		t.Fatalf("unexpected vendors, expected: %v, got: %v", expectedVendors, appState.Vendors)
+	}
+}
+
+func Test_StoreSightings(t *testing.T) {
+	t.Parallel()
+
+	db := openStore(t, filepath.Join(t.TempDir(), "netreact.db"))
+	defer func() { _ = db.Close() }()
+
+	startTs := int64(1749913040000)
+	item := state.Item{Ip: "10.0.0.1", Mac: "b4:b6:86:01:02:03", FirstTs: startTs}
+	for _, offset := range []int64{0, 30_000, 60_000, 90_000, 150_000, 400_000} {
+		item.LastTs = startTs + offset
+		db.RecordHost(item)
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatal("error flushing store:", err)
+	}
+
+	sightings, err := db.Sightings(item.Ip, item.Mac, startTs+1, startTs+400_000)
+	if err != nil {
+		t.Fatal("error reading sightings:", err)
+	}
+	expectedSightings := []int64{startTs + 60_000, startTs + 150_000, startTs + 400_000}
+	if !slices.Equal(sightings, expectedSightings) {
+		t.Fatalf("unexpected sightings, expected: %v, got: %v", expectedSightings, sightings)
+	}
+
+	sightings, err = db.Sightings("10.0.0.2", item.Mac, startTs, startTs+400_000)
+	if err != nil || len(sightings) != 0 {
+		t.Fatalf("unexpected sightings of unknown host: %v, error: %v", sightings, err)
+	}
+}
+
+func Test_StoreEvents(t *testing.T) {
+	t.Parallel()
+
+	db := openStore(t, filepath.Join(t.TempDir(), "netreact.db"))
+	defer func() { _ = db.Close() }()
+
+	notifications := []event.Notification{
+		{EventType: "NEW_HOST", Ip: "10.0.0.1", Mac: "b4:b6:86:01:02:03", Ts: 1749913040000},
+		{EventType: "NEW_HOST", Ip: "10.0.0.2", Mac: "b4:b6:86:01:02:04", Ts: 1749913040000},
+		{EventType: "NEW_VENDOR", Ip: "10.0.0.3", Mac: "00:00:00:01:02:03", Ts: 1749913041000},
+	}
+	for _, notification := range notifications {
+		if err := db.StoreEvent(notification); err != nil {
+			t.Fatal("error storing event:", err)
+		}
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatal("error flushing store:", err)
+	}
+
+	stored, err := db.Events(1749913040000, 1749913040999)
+	if err != nil {
+		t.Fatal("error reading events:", err)
+	}
+	if diff := cmp.Diff(stored, notifications[:2]); diff != "" {
+		t.Fatalf("unexpected events: %v", diff)
+	}
+}
+
+func Test_StoreImport(t *testing.T) {
+	t.Parallel()
+
+	db := openStore(t, filepath.Join(t.TempDir(), "netreact.db"))
+	defer func() { _ = db.Close() }()
+
+	appState := state.NewAppState()
+	appState.Items = []state.Item{{Ip: "10.0.0.1", Mac: "b4:b6:86:01:02:03", FirstTs: 1749913040000, LastTs: 1749913040000, Count: 1}}
+	appState.Vendors = []string{"Apple, Inc."}
+	if err := db.Import(appState); err != nil {
+		t.Fatal("error importing app state:", err)
+	}
+
+	loadedAppState, err := db.LoadAppState()
+	if err != nil {
+		t.Fatal("error loading app state:", err)
+	}
+	if diff := cmp.Diff(loadedAppState, appState); diff != "" {
+		t.Fatalf("unexpected app state: %v", diff)
+	}
+}
+
+func Test_StoreRetention(t *testing.T) {
+	t.Parallel()
+
+	db, err := store.Open(filepath.Join(t.TempDir(), "netreact.db"), 60, 1)
+	if err != nil {
+		t.Fatal("error opening store:", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	nowTs := time.Now().UnixMilli()
+	oldTs := nowTs - 2*24*3600*1000
+	oldItem := state.Item{Ip: "10.0.0.1", Mac: "b4:b6:86:01:02:03", FirstTs: oldTs, LastTs: oldTs}
+	item := state.Item{Ip: "10.0.0.2", Mac: "b4:b6:86:01:02:04", FirstTs: oldTs, LastTs: oldTs}
+	db.RecordHost(oldItem)
+	db.RecordHost(item)
+	item.LastTs = nowTs
+	db.RecordHost(item)
+	notifications := []event.Notification{
+		{EventType: "NEW_HOST", Ip: "10.0.0.1", Mac: "b4:b6:86:01:02:03", Ts: oldTs},
+		{EventType: "NEW_HOST", Ip: "10.0.0.2", Mac: "b4:b6:86:01:02:04", Ts: nowTs},
+	}
+	for _, notification := range notifications {
+		if err = db.StoreEvent(notification); err != nil {
+			t.Fatal("error storing event:", err)
+		}
+	}
+	if err = db.Flush(); err != nil {
+		t.Fatal("error flushing store:", err)
+	}
+
+	// the hosts themselves are kept, only their sightings expire
+	appState, err := db.LoadAppState()
+	if err != nil || len(appState.Items) != 2 {
+		t.Fatalf("unexpected hosts: %v, error: %v", appState.Items, err)
+	}
+	if sightings, err := db.Sightings(oldItem.Ip, oldItem.Mac, 0, nowTs); err != nil || len(sightings) != 0 {
+		t.Fatalf("unexpected sightings: %v, error: %v", sightings, err)
+	}
+	if sightings, err := db.Sightings(item.Ip, item.Mac, 0, nowTs); err != nil || !slices.Equal(sightings, []int64{nowTs}) {
+		t.Fatalf("unexpected sightings: %v, error: %v", sightings, err)
+	}
+	stored, err := db.Events(0, nowTs)
+	if err != nil {
+		t.Fatal("error reading events:", err)
+	}
+	if diff := cmp.Diff(stored, notifications[1:]); diff != "" {
+		t.Fatalf("unexpected events: %v", diff)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"slices"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -37,20 +38,30 @@ var columns = []Column{
 // ui data structure
 
 type UIEntry struct {
-	IP        string
-	MAC       string
-	MACVendor string
-	FirstTs   string
-	LastTs    string
-	Count     int
+	IP                  string
+	MAC                 string
+	MACVendor           string
+	FirstTs             string
+	FirstTsMs           int64
+	LastTs              string
+	Count               int
+	LocallyAdministered bool
+	Multicast           bool
+	Interface           string
+	Vlan                uint16
+	Labels              []string
+	Notes               string
+	PreviousIps         []string
+	Presence            []cache.Interval
 }
 
 // virtual table: https://github.com/rivo/tview/wiki/VirtualTable
 
 type UIApp struct {
 	tview.TableContentReadOnly
-	app  *tview.Application
-	data *[]UIEntry
+	app      *tview.Application
+	data     *[]UIEntry
+	presence cache.PresencePolicy
 }
 
 func newUIApp(cache cache.HostCache) *UIApp {
@@ -58,6 +69,7 @@ func newUIApp(cache cache.HostCache) *UIApp {
 		TableContentReadOnly: tview.TableContentReadOnly{},
 		app:                  tview.NewApplication(),
 		data:                 initialDataLoad(cache),
+		presence:             cache.Presence,
 	}
 }
 
@@ -68,12 +80,22 @@ func initialDataLoad(cache cache.HostCache) *[]UIEntry {
 		ip := net.IP(k.IpBytes())
 		mac := net.HardwareAddr(k.MacBytes())
 		row := UIEntry{
-			IP:        ip.String(),
-			MAC:       mac.String(),
-			MACVendor: oui.MacToVendor(mac),
-			FirstTs:   unixTsToTime(v.FirstTs),
-			LastTs:    unixTsToTime(v.LastTs),
-			Count:     v.Count,
+			IP:                  ip.String(),
+			MAC:                 mac.String(),
+			MACVendor:           oui.MacToVendor(mac),
+			FirstTs:             unixTsToTime(v.FirstTs),
+			FirstTsMs:           v.FirstTs,
+			LastTs:              unixTsToTime(v.LastTs),
+			Count:               v.Count,
+			LocallyAdministered: oui.IsLocallyAdministered(mac),
+			Multicast:           oui.IsMulticast(mac),
+			Interface:           v.Interface,
+			Vlan:                v.Vlan,
+			Labels:              v.Labels,
+			Notes:               v.Notes,
+			PreviousIps:         v.PreviousIps,
+			// cloned, as the cache keeps extending its own intervals in place
+			Presence: slices.Clone(v.Presence),
 		}
 		data = append(data, row)
 	}
@@ -98,24 +120,48 @@ func (uiApp *UIApp) upsertAndRefreshTable(extArpEvent event.ExtendedArpEvent) {
 	lastTs := unixTsToTime(extArpEvent.Ts)
 	macVendor := extArpEvent.MacVendor
 
+	// row of a randomized MAC, merged into this host
+	rowMac := mac
+	if extArpEvent.CollapsedMac != nil {
+		rowMac = extArpEvent.CollapsedMac.String()
+	}
+
 	// update, if found
 	hosts := uiApp.data
 	for i := range *hosts {
-		if (*hosts)[i].IP == ip && (*hosts)[i].MAC == mac {
+		if (*hosts)[i].IP == ip && (*hosts)[i].MAC == rowMac {
+			(*hosts)[i].MAC = mac
+			(*hosts)[i].MACVendor = macVendor
 			(*hosts)[i].LastTs = lastTs
 			(*hosts)[i].Count = extArpEvent.Count
+			(*hosts)[i].Interface = extArpEvent.Interface
+			(*hosts)[i].Vlan = extArpEvent.Vlan
+			(*hosts)[i].Presence = uiApp.presence.Add((*hosts)[i].Presence, extArpEvent.Ts)
 			return
 		}
 	}
 
 	// insert, if new
 	*hosts = append(*hosts, UIEntry{
-		IP:        ip,
-		MAC:       mac,
-		MACVendor: macVendor,
-		FirstTs:   firstTs,
-		LastTs:    lastTs,
-		Count:     1,
+		IP:                  ip,
+		MAC:                 mac,
+		MACVendor:           macVendor,
+		FirstTs:             firstTs,
+		FirstTsMs:           extArpEvent.FirstTs,
+		LastTs:              lastTs,
+		Count:               1,
+		LocallyAdministered: extArpEvent.LocallyAdministered,
+		Multicast:           extArpEvent.Multicast,
+		Interface:           extArpEvent.Interface,
+		Vlan:                extArpEvent.Vlan,
+		Presence:            uiApp.presence.Add(nil, extArpEvent.Ts),
+	})
+}
+
+func (uiApp *UIApp) removeAndRefreshTable(ip string, mac string) {
+	defer func() { _ = uiApp.app.Draw() }()
+	*uiApp.data = slices.DeleteFunc(*uiApp.data, func(entry UIEntry) bool {
+		return entry.IP == ip && entry.MAC == mac
 	})
 }
 
@@ -127,11 +173,11 @@ func (uiApp *UIApp) GetCell(row int, col int) *tview.TableCell {
 	} else if col == 1 {
 		return tview.NewTableCell(alignLeft(entry.MAC, columns[1].width-1))
 	} else if col == 2 {
-		macVendor := entry.MACVendor
-		if len(entry.MACVendor) > columns[2].width {
+		macVendor := entry.displayVendor()
+		if len(macVendor) > columns[2].width {
 			maxTruncatedLen := columns[2].width - 3
-			truncatedLen := min(len(entry.MACVendor), maxTruncatedLen)
-			truncatedMacVendor := entry.MACVendor[:truncatedLen]
+			truncatedLen := min(len(macVendor), maxTruncatedLen)
+			truncatedMacVendor := macVendor[:truncatedLen]
 			macVendor = fmt.Sprintf("%v...", truncatedMacVendor)
 		}
 		return tview.NewTableCell(alignLeft(macVendor, columns[2].width-1))
@@ -144,6 +190,31 @@ func (uiApp *UIApp) GetCell(row int, col int) *tview.TableCell {
 	}
 }
 
+// displayVendor replaces the unknown vendor with the MAC type, where it explains why the vendor is unknown
+func (entry UIEntry) displayVendor() string {
+	if entry.MACVendor != oui.UnknownVendor {
+		return entry.MACVendor
+	} else if entry.Multicast {
+		return "Multicast MAC"
+	} else if entry.LocallyAdministered {
+		return "Randomized MAC"
+	}
+	return entry.MACVendor
+}
+
+func (uiApp *UIApp) macIps(mac string) []string {
+	var ips []string
+	for _, entry := range *uiApp.data {
+		if entry.MAC == mac {
+			ips = append(ips, entry.IP)
+		}
+	}
+	slices.SortFunc(ips, func(a, b string) int {
+		return slices.Compare(net.ParseIP(a).To16(), net.ParseIP(b).To16())
+	})
+	return ips
+}
+
 func (uiApp *UIApp) GetRowCount() int {
 	return len(*uiApp.data)
 }
@@ -156,8 +227,9 @@ func (uiApp *UIApp) GetColumnCount() int {
 
 func loadUI(uiApp *UIApp, ifaceName string, stateFileName *string) {
 	headerRow := getHeaderRow()
-	table := tview.NewTable().SetEvaluateAllRows(false)
+	table := tview.NewTable().SetEvaluateAllRows(false).SetSelectable(true, false)
 	table.SetContent(uiApp)
+	pages := tview.NewPages()
 
 	newTextView := func(text string, align int) tview.Primitive {
 		return tview.NewTextView().
@@ -166,7 +238,7 @@ func loadUI(uiApp *UIApp, ifaceName string, stateFileName *string) {
 	}
 
 	titleBar := getTitleBar(ifaceName, stateFileName)
-	menuBar := fmt.Sprintf(" ▲ - Scroll Up  |  ▼ - Scroll Down  |  Q / ESC - Quit")
+	menuBar := fmt.Sprintf(" ▲ - Scroll Up  |  ▼ - Scroll Down  |  ENTER - Details  |  Q / ESC - Quit")
 	grid := tview.NewGrid().
 		SetRows(1, 1, 0, 1).
 		SetColumns(0, 0, 0, 0).
@@ -187,13 +259,80 @@ func loadUI(uiApp *UIApp, ifaceName string, stateFileName *string) {
 		return event
 	})
 
-	if err := uiApp.app.SetRoot(grid, true).Run(); err != nil {
+	table.SetSelectedFunc(func(row int, column int) {
+		details := uiApp.getDetails(row)
+		modal := tview.NewModal().
+			SetText(details).
+			AddButtons([]string{"Close"}).
+			SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+				pages.RemovePage("details")
+			})
+		pages.AddPage("details", modal, true, true)
+	})
+
+	pages.AddPage("hosts", grid, true, true)
+	if err := uiApp.app.SetRoot(pages, true).Run(); err != nil {
 		log.SetOutput(os.Stdout)
 		log.Println("Unable to load the UI:", err)
 		os.Exit(1)
 	}
 }
 
+func (uiApp *UIApp) getDetails(row int) string {
+	entry := (*uiApp.data)[row]
+	details := fmt.Sprintf("IP Address: %v\nMAC Address: %v\nMAC Vendor: %v\n", entry.IP, entry.MAC, entry.MACVendor)
+	if entry.LocallyAdministered {
+		details += "Locally administered MAC, possibly randomized\n"
+	}
+	if entry.Multicast {
+		details += "Multicast MAC\n"
+	}
+	details += fmt.Sprintf("First seen: %v\nLast seen: %v\nPacket count: %v\n", entry.FirstTs, entry.LastTs, entry.Count)
+	if entry.Interface != "" {
+		details += fmt.Sprintf("Interface: %v\n", entry.Interface)
+	}
+	if entry.Vlan != 0 {
+		details += fmt.Sprintf("VLAN: %v\n", entry.Vlan)
+	}
+	if len(entry.Labels) > 0 {
+		details += fmt.Sprintf("Labels: %v\n", strings.Join(entry.Labels, ", "))
+	}
+	if entry.Notes != "" {
+		details += fmt.Sprintf("Notes: %v\n", entry.Notes)
+	}
+	if len(entry.PreviousIps) > 0 {
+		details += fmt.Sprintf("Previous IP addresses: %v\n", strings.Join(entry.PreviousIps, ", "))
+	}
+	if len(entry.Presence) > 0 {
+		uptimePct := uiApp.presence.UptimePct(entry.Presence, entry.FirstTsMs, time.Now().UnixMilli())
+		details += fmt.Sprintf("Uptime: %.1f%%\n", uptimePct)
+		if hours := cache.ActiveHours(entry.Presence, time.Local); len(hours) > 0 {
+			details += fmt.Sprintf("Typical active hours: %v\n", formatHours(hours))
+		}
+	}
+
+	ips := uiApp.macIps(entry.MAC)
+	details += fmt.Sprintf("\nIP addresses claimed by this MAC (%v):\n", len(ips))
+	for _, ip := range ips {
+		details += ip + "\n"
+	}
+	return details
+}
+
+// formatHours collapses consecutive hours into ranges, e.g. [8 9 10 17] into "08:00-11:00, 17:00-18:00"
+func formatHours(hours []int) string {
+	var ranges []string
+	for i := 0; i < len(hours); {
+		j := i
+		for j+1 < len(hours) && hours[j+1] == hours[j]+1 {
+			j++
+		}
+		ranges = append(ranges, fmt.Sprintf("%02d:00-%02d:00", hours[i], (hours[j]+1)%24))
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
+
 func getTitleBar(ifaceName string, stateFileName *string) string {
 	titleBar := fmt.Sprintf(" Netreact  |  Interface: %v ", ifaceName)
 	if stateFileName != nil {